@@ -0,0 +1,126 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/anatollupacescu/arbortest/report"
+)
+
+var errUsage = errors.New("usage")
+
+// analyze prints the critical path of a run document and the slack of its groups.
+func analyze(args []string, w io.Writer) error {
+	fs := flag.NewFlagSet("analyze", flag.ContinueOnError)
+	asJSON := fs.Bool("json", false, "print the analysis as json")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if fs.NArg() != 1 {
+		return fmt.Errorf("%w: arbortest analyze [-json] run.json", errUsage)
+	}
+
+	run, err := readRun(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	a, err := report.Analyze(run)
+	if err != nil {
+		return fmt.Errorf("analyze %s: %w", fs.Arg(0), err)
+	}
+
+	if *asJSON {
+		return json.NewEncoder(w).Encode(a)
+	}
+
+	printAnalysis(w, run, a)
+
+	return nil
+}
+
+func readRun(path string) (report.Run, error) {
+	f, err := os.Open(filepath.Clean(path))
+	if err != nil {
+		return report.Run{}, err
+	}
+
+	defer func() {
+		_ = f.Close()
+	}()
+
+	run, err := report.Decode(f)
+	if err != nil {
+		return run, fmt.Errorf("read %s: %w", path, err)
+	}
+
+	return run, nil
+}
+
+func printAnalysis(w io.Writer, run report.Run, a report.Analysis) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintf(tw, "sequential:\t%s\n", a.Sequential)
+	fmt.Fprintf(tw, "minimum with unlimited parallelism:\t%s", a.Minimum)
+
+	if a.Minimum > 0 {
+		fmt.Fprintf(tw, ", %.2f times faster", float64(a.Sequential)/float64(a.Minimum))
+	}
+
+	fmt.Fprintln(tw)
+	fmt.Fprintln(tw, "\ncritical path, slowest tests first:")
+
+	for _, id := range a.CriticalPath {
+		tests := testsOf(run, id)
+		sort.SliceStable(tests, func(i, j int) bool {
+			return tests[i].Duration > tests[j].Duration
+		})
+
+		fmt.Fprintf(tw, "  %s\t%s\n", id, durationOf(a, id))
+
+		for _, t := range tests {
+			fmt.Fprintf(tw, "    %s\t%s\n", t.Label, t.Duration)
+		}
+	}
+
+	fmt.Fprintln(tw, "\ngroups off the critical path:\tstart\tslack")
+
+	for _, s := range a.Groups {
+		if s.Slack > 0 {
+			fmt.Fprintf(tw, "  %s\t%s\t%s\n", s.ID, s.Start, s.Slack)
+		}
+	}
+
+	_ = tw.Flush()
+}
+
+func testsOf(run report.Run, group string) []report.Node {
+	var tests []report.Node
+
+	for _, n := range run.Nodes {
+		if n.Kind == report.KindTest && n.Group == group {
+			tests = append(tests, n)
+		}
+	}
+
+	return tests
+}
+
+func durationOf(a report.Analysis, id string) string {
+	for _, s := range a.Groups {
+		if s.ID == id {
+			return s.Duration.String()
+		}
+	}
+
+	return ""
+}
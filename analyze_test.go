@@ -0,0 +1,49 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const timedRun = `{"version":2,"nodes":[` +
+	`{"id":"a","kind":"group","status":"pass","duration":100000000},` +
+	`{"id":"a/slow","label":"slow","kind":"test","group":"a","status":"pass","duration":80000000},` +
+	`{"id":"a/fast","label":"fast","kind":"test","group":"a","status":"pass","duration":20000000},` +
+	`{"id":"b","kind":"group","status":"pass","duration":300000000},` +
+	`{"id":"c","kind":"group","status":"pass","duration":100000000}],` +
+	`"links":[{"source":"a/slow","target":"a","value":1},{"source":"a/fast","target":"a","value":1},` +
+	`{"source":"b","target":"a","value":3}]}`
+
+func TestAnalyzeCommand(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "run.json")
+	assert.NoError(t, ioutil.WriteFile(path, []byte(timedRun), 0600))
+
+	var out bytes.Buffer
+
+	assert.NoError(t, analyze([]string{path}, &out))
+
+	expected := `sequential:                          500ms
+minimum with unlimited parallelism:  400ms, 1.25 times faster
+
+critical path, slowest tests first:
+  a       100ms
+    slow  80ms
+    fast  20ms
+  b       300ms
+
+groups off the critical path:  start  slack
+  c                            0s     300ms
+`
+
+	assert.Equal(t, expected, out.String())
+}
+
+func TestAnalyzeCommandUsage(t *testing.T) {
+	err := analyze(nil, ioutil.Discard)
+
+	assert.EqualError(t, err, "usage: arbortest analyze [-json] run.json")
+}
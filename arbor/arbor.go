@@ -2,6 +2,7 @@ package arbor
 
 import (
 	"fmt"
+	"strings"
 )
 
 type (
@@ -55,7 +56,12 @@ func buildGraph(testFiles []File) (graph, error) {
 
 	for _, fileName := range testFiles {
 		source := fileName.Read()
-		newBundles := parse(source)
+
+		newBundles, err := parse(source)
+		if err != nil {
+			return graph{}, err
+		}
+
 		bundles = append(bundles, newBundles...)
 	}
 
@@ -63,3 +69,103 @@ func buildGraph(testFiles []File) (graph, error) {
 
 	return built, err
 }
+
+// Package describes the groups declared by the tests of a directory.
+type Package struct {
+	// Name of the package, without the suffix of external test packages.
+	Name string
+	// Groups in the order they run.
+	Groups []Group
+}
+
+// Group describes a group as declared in the test files.
+type Group struct {
+	Name string
+	// After lists the groups this one runs after, the ones of other packages
+	// qualified by the package name, e.g. inventory.ingredient.
+	After []string
+	Tests []string
+	// Locks and SharedLocks name the locks the tests of the group hold,
+	// exclusively and shared, each of them once.
+	Locks, SharedLocks []string
+}
+
+// External returns the groups of other packages the group runs after.
+func (g Group) External() []string {
+	var deps []string
+
+	for _, dep := range g.After {
+		if external(dep) {
+			deps = append(deps, dep)
+		}
+	}
+
+	return deps
+}
+
+// Inspect parses the tests of a directory without generating anything.
+func Inspect(dir Dir) (Package, error) {
+	var testFiles = dir.List()
+	if len(testFiles) == 0 {
+		return Package{}, ErrNoTestFilesFound
+	}
+
+	graph, err := buildGraph(testFiles)
+	if err != nil {
+		return Package{}, err
+	}
+
+	if graph.isEmpty() {
+		return Package{}, ErrNoTestsDeclared
+	}
+
+	name, err := packageName(testFiles[0].Read())
+	if err != nil {
+		return Package{}, err
+	}
+
+	pkg := Package{
+		Name: strings.TrimSuffix(name, "_test"),
+	}
+
+	for _, name := range graph.order {
+		grp := graph.groups[name]
+
+		locks, shared := heldLocks(grp.Tests)
+
+		pkg.Groups = append(pkg.Groups, Group{
+			Name:        name,
+			After:       grp.Deps,
+			Tests:       titles(grp.Tests),
+			Locks:       locks,
+			SharedLocks: shared,
+		})
+	}
+
+	return pkg, nil
+}
+
+// heldLocks returns the names of the locks the tests hold, in the order they
+// are first declared.
+func heldLocks(tests []testDescriptor) (locks, shared []string) {
+	seen := make(map[string]bool)
+	sharedSeen := make(map[string]bool)
+
+	for _, t := range tests {
+		for _, l := range t.Locks {
+			if !seen[l] {
+				seen[l] = true
+				locks = append(locks, l)
+			}
+		}
+
+		for _, l := range t.SharedLocks {
+			if !sharedSeen[l] {
+				sharedSeen[l] = true
+				shared = append(shared, l)
+			}
+		}
+	}
+
+	return locks, shared
+}
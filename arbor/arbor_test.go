@@ -90,12 +90,20 @@ import (
 func TestArbor(t *testing.T) {
 	g := arbor.New()
 
-	t.Run("one", func(t *testing.T) {
-		at := arbor.NewT(t)
-		g.Group("one")
+	g.Plan(
+		arbor.PlannedGroup{Name: "one", Tests: []string{"One"}},
+	)
+
+	g.Define("one", func(at *arbor.T) {
 		g.Append(at, "One", testOne)
 	})
 
+	g.Run(t)
+
+	g.End()
+
+	t.Log(g.Summary())
+
 	output := g.JSON()
 
 	arbor.Upload(output)
@@ -143,13 +151,21 @@ import (
 func TestArbor(t *testing.T) {
 	g := arbor.New()
 
-	t.Run("one", func(t *testing.T) {
-		at := arbor.NewT(t)
-		g.Group("one")
+	g.Plan(
+		arbor.PlannedGroup{Name: "one", Tests: []string{"One","Two"}},
+	)
+
+	g.Define("one", func(at *arbor.T) {
 		g.Append(at, "One", testOne)
 		g.Append(at, "Two", testTwo)
 	})
 
+	g.Run(t)
+
+	g.End()
+
+	t.Log(g.Summary())
+
 	output := g.JSON()
 
 	arbor.Upload(output)
@@ -197,18 +213,25 @@ import (
 func TestArbor(t *testing.T) {
 	g := arbor.New()
 
-	t.Run("one", func(t *testing.T) {
-		at := arbor.NewT(t)
-		g.Group("one")
+	g.Plan(
+		arbor.PlannedGroup{Name: "one", Tests: []string{"One"}},
+		arbor.PlannedGroup{Name: "two", Tests: []string{"Two"}},
+	)
+
+	g.Define("one", func(at *arbor.T) {
 		g.Append(at, "One", testOne)
 	})
 
-	t.Run("two", func(t *testing.T) {
-		at := arbor.NewT(t)
-		g.Group("two")
+	g.Define("two", func(at *arbor.T) {
 		g.Append(at, "Two", testTwo)
 	})
 
+	g.Run(t)
+
+	g.End()
+
+	t.Log(g.Summary())
+
 	output := g.JSON()
 
 	arbor.Upload(output)
@@ -256,19 +279,25 @@ import (
 func TestArbor(t *testing.T) {
 	g := arbor.New()
 
-	t.Run("z", func(t *testing.T) {
-		at := arbor.NewT(t)
-		g.Group("z")
+	g.Plan(
+		arbor.PlannedGroup{Name: "z", Tests: []string{"NotEmpty"}},
+		arbor.PlannedGroup{Name: "one", After: []string{"z"}, Tests: []string{"One"}},
+	)
+
+	g.Define("z", func(at *arbor.T) {
 		g.Append(at, "NotEmpty", testNotEmpty)
 	})
 
-	t.Run("one", func(t *testing.T) {
-		at := arbor.NewT(t)
-		g.Group("one")
-		g.After(at, "z")
+	g.Define("one", func(at *arbor.T) {
 		g.Append(at, "One", testOne)
 	})
 
+	g.Run(t)
+
+	g.End()
+
+	t.Log(g.Summary())
+
 	output := g.JSON()
 
 	arbor.Upload(output)
@@ -319,25 +348,30 @@ import (
 func TestArbor(t *testing.T) {
 	g := arbor.New()
 
-	t.Run("two", func(t *testing.T) {
-		at := arbor.NewT(t)
-		g.Group("two")
+	g.Plan(
+		arbor.PlannedGroup{Name: "two", Tests: []string{"Two"}},
+		arbor.PlannedGroup{Name: "z", Tests: []string{"NotEmpty"}},
+		arbor.PlannedGroup{Name: "one", After: []string{"z"}, Tests: []string{"One"}},
+	)
+
+	g.Define("two", func(at *arbor.T) {
 		g.Append(at, "Two", testTwo)
 	})
 
-	t.Run("z", func(t *testing.T) {
-		at := arbor.NewT(t)
-		g.Group("z")
+	g.Define("z", func(at *arbor.T) {
 		g.Append(at, "NotEmpty", testNotEmpty)
 	})
 
-	t.Run("one", func(t *testing.T) {
-		at := arbor.NewT(t)
-		g.Group("one")
-		g.After(at, "z")
+	g.Define("one", func(at *arbor.T) {
 		g.Append(at, "One", testOne)
 	})
 
+	g.Run(t)
+
+	g.End()
+
+	t.Log(g.Summary())
+
 	output := g.JSON()
 
 	arbor.Upload(output)
@@ -388,26 +422,30 @@ import (
 func TestArbor(t *testing.T) {
 	g := arbor.New()
 
-	t.Run("z", func(t *testing.T) {
-		at := arbor.NewT(t)
-		g.Group("z")
+	g.Plan(
+		arbor.PlannedGroup{Name: "z", Tests: []string{"NotEmpty"}},
+		arbor.PlannedGroup{Name: "one", After: []string{"z"}, Tests: []string{"One"}},
+		arbor.PlannedGroup{Name: "two", After: []string{"one"}, Tests: []string{"Two"}},
+	)
+
+	g.Define("z", func(at *arbor.T) {
 		g.Append(at, "NotEmpty", testNotEmpty)
 	})
 
-	t.Run("one", func(t *testing.T) {
-		at := arbor.NewT(t)
-		g.Group("one")
-		g.After(at, "z")
+	g.Define("one", func(at *arbor.T) {
 		g.Append(at, "One", testOne)
 	})
 
-	t.Run("two", func(t *testing.T) {
-		at := arbor.NewT(t)
-		g.Group("two")
-		g.After(at, "one")
+	g.Define("two", func(at *arbor.T) {
 		g.Append(at, "Two", testTwo)
 	})
 
+	g.Run(t)
+
+	g.End()
+
+	t.Log(g.Summary())
+
 	output := g.JSON()
 
 	arbor.Upload(output)
@@ -468,20 +506,143 @@ import (
 func TestArbor(t *testing.T) {
 	g := arbor.New()
 
-	t.Run("two", func(t *testing.T) {
-		at := arbor.NewT(t)
-		g.Group("two")
+	g.Plan(
+		arbor.PlannedGroup{Name: "two", Tests: []string{"Two"}},
+		arbor.PlannedGroup{Name: "one", After: []string{"two"}, Tests: []string{"One","Three"}},
+	)
+
+	g.Define("two", func(at *arbor.T) {
 		g.Append(at, "Two", testTwo)
 	})
 
-	t.Run("one", func(t *testing.T) {
-		at := arbor.NewT(t)
-		g.Group("one")
-		g.After(at, "two")
+	g.Define("one", func(at *arbor.T) {
 		g.Append(at, "One", testOne)
 		g.Append(at, "Three", testThree)
 	})
 
+	g.Run(t)
+
+	g.End()
+
+	t.Log(g.Summary())
+
+	output := g.JSON()
+
+	arbor.Upload(output)
+}
+`
+		assert.Equal(t, expected, outFile.contents)
+	})
+}
+
+func TestContinueOnFailure(t *testing.T) {
+	var src = `package random
+
+import "github.com/anatollupacescu/arbortest/runner"
+
+// group:one onfail:continue
+func testOne(t *runner.T) {}
+
+// group:one
+func testTwo(t *runner.T) {}
+`
+
+	var (
+		testProviderFile = TestFile(src)
+		singleFileDir    = TestDir(func() []arbor.File {
+			return []arbor.File{&testProviderFile}
+		})
+		outFile = &TestOutFile{}
+	)
+
+	t.Run("declares the group policy", func(t *testing.T) {
+		err := arbor.Generate(&singleFileDir, outFile, "sample")
+		assert.NoError(t, err)
+		expected := `package sample
+
+import (
+	"testing"
+
+	arbor "github.com/anatollupacescu/arbortest/runner"
+)
+
+func TestArbor(t *testing.T) {
+	g := arbor.New()
+
+	g.Plan(
+		arbor.PlannedGroup{Name: "one", Tests: []string{"One","Two"}},
+	)
+
+	g.Define("one", func(at *arbor.T) {
+		g.OnFail(arbor.Continue)
+		g.Append(at, "One", testOne)
+		g.Append(at, "Two", testTwo)
+	})
+
+	g.Run(t)
+
+	g.End()
+
+	t.Log(g.Summary())
+
+	output := g.JSON()
+
+	arbor.Upload(output)
+}
+`
+		assert.Equal(t, expected, outFile.contents)
+	})
+}
+
+func TestLocks(t *testing.T) {
+	var src = `package random
+
+import "github.com/anatollupacescu/arbortest/runner"
+
+// group:one lock:db,port
+func testOne(t *runner.T) {}
+
+// group:one rlock:db
+func testTwo(t *runner.T) {}
+`
+
+	var (
+		testProviderFile = TestFile(src)
+		singleFileDir    = TestDir(func() []arbor.File {
+			return []arbor.File{&testProviderFile}
+		})
+		outFile = &TestOutFile{}
+	)
+
+	t.Run("passes the locks to the tests", func(t *testing.T) {
+		err := arbor.Generate(&singleFileDir, outFile, "sample")
+		assert.NoError(t, err)
+		expected := `package sample
+
+import (
+	"testing"
+
+	arbor "github.com/anatollupacescu/arbortest/runner"
+)
+
+func TestArbor(t *testing.T) {
+	g := arbor.New()
+
+	g.Plan(
+		arbor.PlannedGroup{Name: "one", Tests: []string{"One","Two"}},
+	)
+
+	g.Define("one", func(at *arbor.T) {
+		g.Append(at, "One", testOne, arbor.Exclusive("db"), arbor.Exclusive("port"))
+		g.Append(at, "Two", testTwo, arbor.Shared("db"))
+	})
+
+	g.Run(t)
+
+	g.End()
+
+	t.Log(g.Summary())
+
 	output := g.JSON()
 
 	arbor.Upload(output)
@@ -513,3 +674,51 @@ func (t *TestOutFile) Write(contents string) error {
 	t.contents = contents
 	return t.err
 }
+
+func TestInspect(t *testing.T) {
+	var src = `package shop_test
+
+import "github.com/anatollupacescu/arbortest/runner"
+
+// group:order after:inventory.ingredient,cart
+func testPlace(t *runner.T) {}
+
+// group:cart lock:db rlock:cache
+func testAdd(t *runner.T) {}
+
+// group:cart lock:db
+func testRemove(t *runner.T) {}`
+
+	var (
+		file = TestFile(src)
+		dir  = TestDir(func() []arbor.File {
+			return []arbor.File{&file}
+		})
+	)
+
+	pkg, err := arbor.Inspect(&dir)
+	assert.NoError(t, err)
+
+	expected := arbor.Package{
+		Name: "shop",
+		Groups: []arbor.Group{
+			{Name: "cart", Tests: []string{"Add", "Remove"}, Locks: []string{"db"}, SharedLocks: []string{"cache"}},
+			{Name: "order", After: []string{"inventory.ingredient", "cart"}, Tests: []string{"Place"}},
+		},
+	}
+
+	assert.Equal(t, expected, pkg)
+	assert.Equal(t, []string{"inventory.ingredient"}, pkg.Groups[1].External())
+}
+
+func TestInspectMalformedFile(t *testing.T) {
+	var (
+		file = TestFile("package shop_test\n\nfunc testPlace(")
+		dir  = TestDir(func() []arbor.File {
+			return []arbor.File{&file}
+		})
+	)
+
+	_, err := arbor.Inspect(&dir)
+	assert.Error(t, err)
+}
@@ -55,6 +55,7 @@ var (
 	errMissingGroupDeclaration = errors.New("missing group declaration")
 	errDuplicateToken          = errors.New("duplicate token")
 	errUnexpectedSegmentKind   = errors.New("unexpected kind")
+	errConflictingLock         = errors.New("lock declared both exclusive and shared")
 )
 
 func applyBundle(g graph, bundle testBundle) error {
@@ -66,6 +67,8 @@ func applyBundle(g graph, bundle testBundle) error {
 		groupID       string
 		afterDeclared bool
 		dependencies  []string
+		onFail        string
+		locks, shared []string
 	)
 
 	inputs := strings.Split(c, " ")
@@ -89,6 +92,24 @@ func applyBundle(g graph, bundle testBundle) error {
 
 			afterDeclared = true
 			dependencies = seg.dependencies
+		case "onfail":
+			if onFail != "" {
+				return errDuplicateToken
+			}
+
+			onFail = seg.onFail
+		case "lock":
+			if locks != nil {
+				return errDuplicateToken
+			}
+
+			locks = seg.locks
+		case "rlock":
+			if shared != nil {
+				return errDuplicateToken
+			}
+
+			shared = seg.locks
 		default:
 			return errUnexpectedSegmentKind
 		}
@@ -98,12 +119,22 @@ func applyBundle(g graph, bundle testBundle) error {
 		return errMissingGroupDeclaration
 	}
 
+	for _, l := range locks {
+		for _, r := range shared {
+			if l == r {
+				return errConflictingLock
+			}
+		}
+	}
+
 	testDesc := testDescriptor{
-		Name:  bundle.testName,
-		Title: bundle.testTitle,
+		Name:        bundle.testName,
+		Title:       bundle.testTitle,
+		Locks:       locks,
+		SharedLocks: shared,
 	}
 
-	if err := g.addGroup(groupID, dependencies, testDesc); err != nil {
+	if err := g.addGroup(groupID, dependencies, onFail, testDesc); err != nil {
 		return err
 	}
 
@@ -114,6 +145,8 @@ type segment struct {
 	kind         string
 	groupID      string
 	dependencies []string
+	onFail       string
+	locks        []string
 }
 
 const keyValuePairSize = 2
@@ -145,8 +178,30 @@ func newFromString(in string) (segment, error) {
 			return seg, err
 		}
 
+		for _, dep := range dependencies {
+			if !validDependency(dep) {
+				return seg, errBadToken
+			}
+		}
+
 		seg.kind = kind
 		seg.dependencies = dependencies
+	case "onfail":
+		policy, err := extractPolicy(components[1])
+		if err != nil {
+			return seg, err
+		}
+
+		seg.kind = kind
+		seg.onFail = policy
+	case "lock", "rlock":
+		locks, err := extractDependencies(components[1])
+		if err != nil {
+			return seg, err
+		}
+
+		seg.kind = kind
+		seg.locks = locks
 	default:
 		return seg, errBadToken
 	}
@@ -155,13 +210,33 @@ func newFromString(in string) (segment, error) {
 }
 
 func extractGroupID(in string) (string, error) {
-	if strings.Contains(in, ",") {
+	// dots qualify the groups of other packages
+	if strings.ContainsAny(in, ",.") {
 		return "", errBadToken
 	}
 
 	return in, nil
 }
 
+// validDependency accepts group names and groups of other packages, as package.group.
+func validDependency(dep string) bool {
+	parts := strings.Split(dep, ".")
+
+	switch len(parts) {
+	case 1:
+		return true
+	case 2:
+		return parts[0] != "" && parts[1] != ""
+	default:
+		return false
+	}
+}
+
+// external tells whether the dependency is a group of another package.
+func external(dep string) bool {
+	return strings.Contains(dep, ".")
+}
+
 var errEmptyValueNotAllowed = errors.New("empty value not allowed")
 
 func extractDependencies(component string) (elements []string, err error) {
@@ -180,3 +255,20 @@ func extractDependencies(component string) (elements []string, err error) {
 
 	return elements, nil
 }
+
+// Policies a group can declare for the tests following a failed one.
+const (
+	policyStop     = "stop"
+	policyContinue = "continue"
+)
+
+func extractPolicy(in string) (string, error) {
+	switch in {
+	case policyStop, policyContinue:
+		return in, nil
+	case "":
+		return "", errEmptyValueNotAllowed
+	default:
+		return "", errBadToken
+	}
+}
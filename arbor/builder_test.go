@@ -65,7 +65,7 @@ func TestTable(t *testing.T) {
 				order: []string{"a"},
 				groups: map[string]testGroup{
 					"a": {
-						Tests: []testDescriptor{{"test1", "test1"}, {"test2", "test2"}},
+						Tests: []testDescriptor{{Name: "test1", Title: "test1"}, {Name: "test2", Title: "test2"}},
 					},
 				},
 			},
@@ -78,7 +78,7 @@ func TestTable(t *testing.T) {
 				order: []string{"a"},
 				groups: map[string]testGroup{
 					"a": {
-						Tests: []testDescriptor{{"test1", "test1"}},
+						Tests: []testDescriptor{{Name: "test1", Title: "test1"}},
 					},
 				},
 			},
@@ -98,10 +98,10 @@ func TestTable(t *testing.T) {
 				order: []string{"a", "b"},
 				groups: map[string]testGroup{
 					"a": {
-						Tests: []testDescriptor{{"test1", "test1"}},
+						Tests: []testDescriptor{{Name: "test1", Title: "test1"}},
 					},
 					"b": {
-						Tests: []testDescriptor{{"test2", "test2"}},
+						Tests: []testDescriptor{{Name: "test2", Title: "test2"}},
 						Deps:  []string{"a"},
 					},
 				},
@@ -116,11 +116,11 @@ func TestTable(t *testing.T) {
 				order: []string{"b", "a"},
 				groups: map[string]testGroup{
 					"a": {
-						Tests: []testDescriptor{{"test1", "test1"}},
+						Tests: []testDescriptor{{Name: "test1", Title: "test1"}},
 						Deps:  []string{"b"},
 					},
 					"b": {
-						Tests: []testDescriptor{{"test2", "test2"}},
+						Tests: []testDescriptor{{Name: "test2", Title: "test2"}},
 					},
 				},
 			},
@@ -135,13 +135,13 @@ func TestTable(t *testing.T) {
 				order: []string{"a", "b", "c"},
 				groups: map[string]testGroup{
 					"a": {
-						Tests: []testDescriptor{{"test1", "test1"}},
+						Tests: []testDescriptor{{Name: "test1", Title: "test1"}},
 					},
 					"b": {
-						Tests: []testDescriptor{{"test2", "test2"}},
+						Tests: []testDescriptor{{Name: "test2", Title: "test2"}},
 					},
 					"c": {
-						Tests: []testDescriptor{{"test3", "test3"}},
+						Tests: []testDescriptor{{Name: "test3", Title: "test3"}},
 						Deps:  []string{"a", "b"},
 					},
 				},
@@ -157,14 +157,14 @@ func TestTable(t *testing.T) {
 				order: []string{"a", "b", "c"},
 				groups: map[string]testGroup{
 					"a": {
-						Tests: []testDescriptor{{"test1", "test1"}},
+						Tests: []testDescriptor{{Name: "test1", Title: "test1"}},
 					},
 					"b": {
-						Tests: []testDescriptor{{"test2", "test2"}},
+						Tests: []testDescriptor{{Name: "test2", Title: "test2"}},
 						Deps:  []string{"a"},
 					},
 					"c": {
-						Tests: []testDescriptor{{"test3", "test3"}},
+						Tests: []testDescriptor{{Name: "test3", Title: "test3"}},
 						Deps:  []string{"a", "b"},
 					},
 				},
@@ -185,6 +185,101 @@ func TestTable(t *testing.T) {
 				{testName: "test4", testTitle: "test4", comment: "// group:c"},
 			},
 			err: "repeated declaration of 'after' for current group near 'test2':\n// group:a after:c",
+		}, {
+			name: "unknown 'onfail' policy",
+			inputs: []testBundle{
+				{testName: "test1", testTitle: "test1", comment: "// group:a onfail:retry"},
+			},
+			err: "bad token near 'test1':\n// group:a onfail:retry",
+		}, {
+			name: "empty 'onfail' policy",
+			inputs: []testBundle{
+				{testName: "test1", testTitle: "test1", comment: "// group:a onfail:"},
+			},
+			err: "empty value not allowed near 'test1':\n// group:a onfail:",
+		}, {
+			name: "rejects conflicting 'onfail' declarations within the same group",
+			inputs: []testBundle{
+				{testName: "test1", testTitle: "test1", comment: "// group:a onfail:continue"},
+				{testName: "test2", testTitle: "test2", comment: "// group:a onfail:stop"},
+			},
+			err: "conflicting declaration of 'onfail' for current group near 'test2':\n// group:a onfail:stop",
+		}, {
+			name: "'onfail' declared once applies to the whole group",
+			inputs: []testBundle{
+				{testName: "test1", testTitle: "test1", comment: "// group:a"},
+				{testName: "test2", testTitle: "test2", comment: "// group:a onfail:continue"},
+			},
+			expected: graph{
+				order: []string{"a"},
+				groups: map[string]testGroup{
+					"a": {
+						Tests:  []testDescriptor{{Name: "test1", Title: "test1"}, {Name: "test2", Title: "test2"}},
+						OnFail: "continue",
+					},
+				},
+			},
+		}, {
+			name: "exclusive and shared locks",
+			inputs: []testBundle{
+				{testName: "test1", testTitle: "test1", comment: "// group:a lock:db,port rlock:cache"},
+			},
+			expected: graph{
+				order: []string{"a"},
+				groups: map[string]testGroup{
+					"a": {
+						Tests: []testDescriptor{{Name: "test1", Title: "test1", Locks: []string{"db", "port"}, SharedLocks: []string{"cache"}}},
+					},
+				},
+			},
+		}, {
+			name: "empty lock",
+			inputs: []testBundle{
+				{testName: "test1", testTitle: "test1", comment: "// group:a lock:"},
+			},
+			err: "empty value not allowed near 'test1':\n// group:a lock:",
+		}, {
+			name: "repeated lock declaration",
+			inputs: []testBundle{
+				{testName: "test1", testTitle: "test1", comment: "// group:a lock:db lock:port"},
+			},
+			err: "duplicate token near 'test1':\n// group:a lock:db lock:port",
+		}, {
+			name: "lock both exclusive and shared",
+			inputs: []testBundle{
+				{testName: "test1", testTitle: "test1", comment: "// group:a lock:db rlock:db"},
+			},
+			err: "lock declared both exclusive and shared near 'test1':\n// group:a lock:db rlock:db",
+		}, {
+			name: "groups of other packages are not looked up",
+			inputs: []testBundle{
+				{testName: "test1", testTitle: "test1", comment: "// group:a after:inventory.ingredient,b"},
+				{testName: "test2", testTitle: "test2", comment: "// group:b"},
+			},
+			expected: graph{
+				order: []string{"b", "a"},
+				groups: map[string]testGroup{
+					"a": {
+						Tests: []testDescriptor{{Name: "test1", Title: "test1"}},
+						Deps:  []string{"inventory.ingredient", "b"},
+					},
+					"b": {
+						Tests: []testDescriptor{{Name: "test2", Title: "test2"}},
+					},
+				},
+			},
+		}, {
+			name: "group of another package qualified twice",
+			inputs: []testBundle{
+				{testName: "test1", testTitle: "test1", comment: "// group:a after:shop.inventory.ingredient"},
+			},
+			err: "bad token near 'test1':\n// group:a after:shop.inventory.ingredient",
+		}, {
+			name: "dot in group name",
+			inputs: []testBundle{
+				{testName: "test1", testTitle: "test1", comment: "// group:inventory.a"},
+			},
+			err: "bad token near 'test1':\n// group:inventory.a",
 		}, {
 			name: "orders by dependencies",
 			inputs: []testBundle{
@@ -197,15 +292,15 @@ func TestTable(t *testing.T) {
 				order: []string{"c", "b", "a"},
 				groups: map[string]testGroup{
 					"a": {
-						Tests: []testDescriptor{{"test1", "test1"}, {"test2", "test2"}},
+						Tests: []testDescriptor{{Name: "test1", Title: "test1"}, {Name: "test2", Title: "test2"}},
 						Deps:  []string{"b", "c"},
 					},
 					"b": {
-						Tests: []testDescriptor{{"test3", "test3"}},
+						Tests: []testDescriptor{{Name: "test3", Title: "test3"}},
 						Deps:  []string{"c"},
 					},
 					"c": {
-						Tests: []testDescriptor{{"test4", "test4"}},
+						Tests: []testDescriptor{{Name: "test4", Title: "test4"}},
 					},
 				},
 			},
@@ -8,11 +8,15 @@ import (
 
 type testDescriptor struct {
 	Name, Title string
+	// Locks are held exclusively while the test runs, SharedLocks along with
+	// other tests holding them shared.
+	Locks, SharedLocks []string
 }
 
 type testGroup struct {
-	Deps  []string
-	Tests []testDescriptor
+	Deps   []string
+	Tests  []testDescriptor
+	OnFail string
 }
 
 type graph struct {
@@ -20,15 +24,26 @@ type graph struct {
 	groups map[string]testGroup
 }
 
-var errRepeatedDeclaration = errors.New("repeated declaration of 'after' for current group")
+var (
+	errRepeatedDeclaration = errors.New("repeated declaration of 'after' for current group")
+	errConflictingPolicy   = errors.New("conflicting declaration of 'onfail' for current group")
+)
 
-func (g graph) addGroup(id string, deps []string, testName testDescriptor) error {
+func (g graph) addGroup(id string, deps []string, onFail string, testName testDescriptor) error {
 	group := g.groups[id]
 
 	if len(group.Deps) > 0 && len(deps) > 0 {
 		return errRepeatedDeclaration
 	}
 
+	if onFail != "" {
+		if group.OnFail != "" && group.OnFail != onFail {
+			return errConflictingPolicy
+		}
+
+		group.OnFail = onFail
+	}
+
 	group.Deps = append(group.Deps, deps...)
 	group.Tests = append(group.Tests, testName)
 
@@ -65,6 +80,10 @@ func (g *graph) saveOrder() {
 
 func dependenciesAreSatisfied(g *graph, group string, left *list.List) bool {
 	for _, dep := range g.groups[group].Deps {
+		if external(dep) {
+			continue
+		}
+
 		var found bool
 
 		for e := left.Front(); e != nil; e = e.Next() {
@@ -102,6 +121,11 @@ func (g graph) missingGroups() error {
 	for _, groupID := range g.order {
 		grp := g.groups[groupID]
 		for _, depID := range grp.Deps {
+			if external(depID) {
+				// checked by the orchestrator, which knows the other packages
+				continue
+			}
+
 			if _, hasGroup := g.groups[depID]; !hasGroup {
 				return fmt.Errorf("%w: %s", errGroupNotFound, depID)
 			}
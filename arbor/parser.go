@@ -1,10 +1,10 @@
 package arbor
 
 import (
+	"fmt"
 	"go/ast"
 	"go/parser"
 	"go/token"
-	"log"
 	"strings"
 )
 
@@ -12,13 +12,13 @@ type testBundle struct {
 	comment, testTitle, testName string
 }
 
-func parse(src string) []testBundle {
+func parse(src string) ([]testBundle, error) {
 	fset := token.NewFileSet()
 
 	f, err := parser.ParseFile(fset, "src.go", src, parser.ParseComments)
 
 	if err != nil {
-		log.Fatalf("parse file: %v\n", err)
+		return nil, fmt.Errorf("parse file: %w", err)
 	}
 
 	var bundles []testBundle
@@ -41,7 +41,16 @@ func parse(src string) []testBundle {
 		}
 	}
 
-	return bundles
+	return bundles, nil
+}
+
+func packageName(src string) (string, error) {
+	f, err := parser.ParseFile(token.NewFileSet(), "src.go", src, parser.PackageClauseOnly)
+	if err != nil {
+		return "", fmt.Errorf("parse file: %w", err)
+	}
+
+	return f.Name.Name, nil
 }
 
 func getComment(gen *ast.FuncDecl) string {
@@ -18,14 +18,22 @@ import (
 
 func TestArbor(t *testing.T) {
 	g := arbor.New()
-{{ $groups := .Groups }}{{ range $elem := .Order }}
-	t.Run({{printf "%q" $elem}}, func(t *testing.T) {
-		at := arbor.NewT(t)
-		g.Group({{ printf "%q" $elem }}){{ $testGroup := (index $groups $elem)}}{{ $len := (len $testGroup.Deps) }}{{ if (gt $len 0) }}
-		g.After(at, {{ $testGroup.Deps | commaSep }}){{end}}{{ range $test := $testGroup.Tests}}
-		g.Append(at, {{ printf "%q" $test.Title }}, {{ $test.Name }}){{ end }}
+{{ $groups := .Groups }}
+	g.Plan({{ range $elem := .Order }}{{ $testGroup := (index $groups $elem) }}
+		arbor.PlannedGroup{Name: {{ printf "%q" $elem }},{{ if $testGroup.Deps }} After: []string{ {{- $testGroup.Deps | commaSep -}} },{{ end }} Tests: []string{ {{- $testGroup.Tests | titles | commaSep -}} }},{{ end }}
+	)
+{{ range $elem := .Order }}{{ $testGroup := (index $groups $elem) }}
+	g.Define({{ printf "%q" $elem }}, func(at *arbor.T) { {{- if (eq $testGroup.OnFail "continue") }}
+		g.OnFail(arbor.Continue){{end}}{{ range $test := $testGroup.Tests}}
+		g.Append(at, {{ printf "%q" $test.Title }}, {{ $test.Name }}{{ range $test.Locks }}, arbor.Exclusive({{ printf "%q" . }}){{ end }}{{ range $test.SharedLocks }}, arbor.Shared({{ printf "%q" . }}){{ end }}){{ end }}
 	})
 {{ end }}
+	g.Run(t)
+
+	g.End()
+
+	t.Log(g.Summary())
+
 	output := g.JSON()
 
 	arbor.Upload(output)
@@ -41,6 +49,15 @@ func commaSep(elems []string) string {
 	return strings.Join(quoted, ",")
 }
 
+func titles(tests []testDescriptor) []string {
+	tt := make([]string, 0, len(tests))
+	for _, t := range tests {
+		tt = append(tt, t.Title)
+	}
+
+	return tt
+}
+
 func generateSource(pkg string, g graph) string {
 	data := struct {
 		Package string
@@ -54,6 +71,7 @@ func generateSource(pkg string, g graph) string {
 
 	fmap := template.FuncMap{
 		"commaSep": commaSep,
+		"titles":   titles,
 	}
 
 	parse, err := template.New("test").Funcs(fmap).Parse(tmpl)
@@ -3,15 +3,50 @@ package main
 import (
 	"fmt"
 	"net/http"
+	"strconv"
+	"time"
 )
 
-type stringChan chan string
+const (
+	// clientQueueSize is the number of events a client may fall behind by
+	// before it gets disconnected, browsers reconnect on their own and catch
+	// up using the Last-Event-ID header.
+	clientQueueSize = 16
+	// heartbeatInterval keeps idle connections open through proxies.
+	heartbeatInterval = 15 * time.Second
+)
+
+// event is a server sent event, its id is the revision of the store in which
+// the run it carries last changed.
+// Progress events are named and have no id, they are not replayed.
+type event struct {
+	id   int
+	name string
+	data string
+}
+
+type eventChan chan event
 
 type broker struct {
-	clients        map[stringChan]struct{}
-	newClients     chan stringChan
-	defunctClients chan stringChan
-	messages       stringChan
+	clients        map[eventChan]struct{}
+	newClients     chan eventChan
+	defunctClients chan eventChan
+	messages       eventChan
+	done           chan struct{}
+
+	// replay returns the events published after the given id.
+	replay func(lastID int) []event
+}
+
+func newBroker(replay func(lastID int) []event) *broker {
+	return &broker{
+		clients:        make(map[eventChan]struct{}),
+		newClients:     make(chan eventChan),
+		defunctClients: make(chan eventChan),
+		messages:       make(eventChan, clientQueueSize),
+		done:           make(chan struct{}),
+		replay:         replay,
+	}
 }
 
 func (b *broker) listen() {
@@ -20,16 +55,48 @@ func (b *broker) listen() {
 		case s := <-b.newClients:
 			b.clients[s] = struct{}{}
 		case s := <-b.defunctClients:
-			delete(b.clients, s)
-			close(s)
+			b.drop(s)
 		case msg := <-b.messages:
 			for s := range b.clients {
-				s <- msg
+				select {
+				case s <- msg:
+				default:
+					// the client is too slow, let it reconnect instead of stalling everyone
+					b.drop(s)
+				}
 			}
+		case <-b.done:
+			for s := range b.clients {
+				b.drop(s)
+			}
+
+			return
 		}
 	}
 }
 
+func (b *broker) drop(s eventChan) {
+	if _, ok := b.clients[s]; !ok {
+		return
+	}
+
+	delete(b.clients, s)
+	close(s)
+}
+
+// publish hands the event to the broker without waiting for the clients.
+func (b *broker) publish(e event) {
+	select {
+	case b.messages <- e:
+	case <-b.done:
+	}
+}
+
+// close disconnects all clients, it is called once the server shuts down.
+func (b *broker) close() {
+	close(b.done)
+}
+
 func (b *broker) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	f, ok := w.(http.Flusher)
 	if !ok {
@@ -37,13 +104,22 @@ func (b *broker) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	messageChan := make(stringChan)
+	messageChan := make(eventChan, clientQueueSize)
 
-	b.newClients <- messageChan
+	select {
+	case b.newClients <- messageChan:
+	case <-b.done:
+		http.Error(w, "shutting down", http.StatusServiceUnavailable)
+		return
+	}
 
 	go func() {
 		<-r.Context().Done()
-		b.defunctClients <- messageChan
+
+		select {
+		case b.defunctClients <- messageChan:
+		case <-b.done:
+		}
 	}()
 
 	enableCors(&w)
@@ -53,14 +129,53 @@ func (b *broker) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Connection", "keep-alive")
 	w.Header().Set("Transfer-Encoding", "chunked")
 
+	var lastID int
+
+	// browsers send the header only when reconnecting
+	if h := r.Header.Get("Last-Event-ID"); h != "" {
+		lastID, _ = strconv.Atoi(h)
+
+		for _, e := range b.replay(lastID) {
+			writeEvent(w, e)
+
+			lastID = e.id
+		}
+	}
+
+	f.Flush()
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+
 	for {
-		msg, open := <-messageChan
+		select {
+		case msg, open := <-messageChan:
+			if !open {
+				return
+			}
 
-		if !open {
-			break
+			if msg.id != 0 && msg.id <= lastID {
+				// already sent while replaying
+				continue
+			}
+
+			writeEvent(w, msg)
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": heartbeat\n\n")
 		}
 
-		fmt.Fprintf(w, "data: %s\n\n", msg)
 		f.Flush()
 	}
 }
+
+func writeEvent(w http.ResponseWriter, e event) {
+	if e.name != "" {
+		fmt.Fprintf(w, "event: %s\n", e.name)
+	}
+
+	if e.id != 0 {
+		fmt.Fprintf(w, "id: %d\n", e.id)
+	}
+
+	fmt.Fprintf(w, "data: %s\n\n", e.data)
+}
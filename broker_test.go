@@ -0,0 +1,131 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// stalledWriter is a client which stops reading, its writes wait until released.
+type stalledWriter struct {
+	*httptest.ResponseRecorder
+
+	once     sync.Once
+	flushed  chan struct{}
+	released chan struct{}
+}
+
+func (w *stalledWriter) Write(b []byte) (int, error) {
+	<-w.released
+
+	return w.ResponseRecorder.Write(b)
+}
+
+func (w *stalledWriter) Flush() {
+	w.ResponseRecorder.Flush()
+	w.once.Do(func() { close(w.flushed) })
+}
+
+func TestSlowClientIsDropped(t *testing.T) {
+	b := newBroker(func(int) []event { return nil })
+
+	go b.listen()
+	defer b.close()
+
+	w := &stalledWriter{
+		ResponseRecorder: httptest.NewRecorder(),
+		flushed:          make(chan struct{}),
+		released:         make(chan struct{}),
+	}
+
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		b.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/events/", nil))
+	}()
+
+	// the client is subscribed once the headers are flushed
+	<-w.flushed
+
+	const published = clientQueueSize + 2
+
+	for i := 1; i <= published; i++ {
+		b.publish(event{id: i, data: "run"})
+	}
+
+	// let the broker go through the events before the client reads again
+	time.Sleep(50 * time.Millisecond)
+	close(w.released)
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("the slow client is still connected")
+	}
+
+	assert.Less(t, strings.Count(w.Body.String(), "data: run"), published, "the events after the queue filled up are not sent")
+}
+
+func TestReconnectedClientCatchesUp(t *testing.T) {
+	srv, _ := newTestServer(t)
+
+	for _, commit := range []string{"first", "second", "third"} {
+		post(t, srv.URL+"/data/", strings.Replace(validRun, "abc", commit, 1))
+	}
+
+	events := readEvents(t, subscribe(t, srv.URL, "1"), 2)
+
+	assert.Equal(t, "id: 2", events[0][0])
+	assert.Contains(t, events[0][1], `"commit":"second"`)
+	assert.Equal(t, "id: 3", events[1][0])
+	assert.Contains(t, events[1][1], `"commit":"third"`)
+}
+
+func TestCloseEndsStreams(t *testing.T) {
+	s := newStore()
+	b := newBroker(s.events)
+
+	go b.listen()
+
+	srv := httptest.NewServer(b)
+	defer srv.Close()
+
+	streams := make([]*http.Response, 2)
+
+	for i := range streams {
+		resp, err := http.Get(srv.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		defer resp.Body.Close()
+
+		streams[i] = resp
+	}
+
+	b.close()
+
+	for i, resp := range streams {
+		ended := make(chan error, 1)
+
+		go func(resp *http.Response) {
+			_, err := ioutil.ReadAll(resp.Body)
+			ended <- err
+		}(resp)
+
+		select {
+		case err := <-ended:
+			assert.NoError(t, err, fmt.Sprintf("stream %d", i))
+		case <-time.After(time.Second):
+			t.Fatalf("stream %d is still open", i)
+		}
+	}
+}
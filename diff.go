@@ -0,0 +1,110 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/anatollupacescu/arbortest/report"
+)
+
+// comparison is the response of the diff endpoint, runs are numbered from 1,
+// starting with the oldest one, the same way the UI lists them.
+type comparison struct {
+	Base int `json:"base"`
+	Head int `json:"head"`
+
+	report.Diff
+}
+
+// serveDiff compares two stored runs, selected by the 'head' and 'base' query
+// parameters. Head defaults to the latest run and base to the one preceding
+// head, base can also be given as a commit hash.
+func serveDiff(stored func() []report.Run) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		enableCors(&w)
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+		if r.Method != "GET" {
+			http.Error(w, "only GET method expected", http.StatusMethodNotAllowed)
+
+			return
+		}
+
+		runs := stored()
+
+		base, head, err := pickRuns(runs, r.URL.Query())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+
+			return
+		}
+
+		c := comparison{
+			Base: base,
+			Head: head,
+			Diff: report.Compare(byNumber(runs, base), byNumber(runs, head)),
+		}
+
+		if err := json.NewEncoder(w).Encode(c); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	}
+}
+
+var (
+	errRunNotFound   = errors.New("run not found")
+	errNoPreviousRun = errors.New("no previous run to compare with")
+)
+
+func pickRuns(runs []report.Run, q url.Values) (base, head int, err error) {
+	head = len(runs)
+
+	if h := q.Get("head"); h != "" {
+		if head, err = runNumber(runs, h); err != nil {
+			return
+		}
+	}
+
+	if head == 0 {
+		return 0, 0, fmt.Errorf("%w: no runs stored", errRunNotFound)
+	}
+
+	b := q.Get("base")
+	if b == "" {
+		if head == 1 {
+			return 0, 0, errNoPreviousRun
+		}
+
+		return head - 1, head, nil
+	}
+
+	if base, err = runNumber(runs, b); err == nil {
+		return base, head, nil
+	}
+
+	for n := len(runs); n > 0; n-- {
+		if n != head && byNumber(runs, n).Commit == b {
+			return n, head, nil
+		}
+	}
+
+	return 0, 0, fmt.Errorf("%w: %s", errRunNotFound, b)
+}
+
+func runNumber(runs []report.Run, s string) (int, error) {
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 1 || n > len(runs) {
+		return 0, fmt.Errorf("%w: %s", errRunNotFound, s)
+	}
+
+	return n, nil
+}
+
+// byNumber returns a run by its number from a snapshot listed newest first.
+func byNumber(runs []report.Run, n int) report.Run {
+	return runs[len(runs)-n]
+}
@@ -9,27 +9,31 @@ import (
 func TestArbor(t *testing.T) {
 	g := arbor.New()
 
-	t.Run("ingredient", func(t *testing.T) {
-		at := arbor.NewT(t)
-		g.Group("ingredient")
+	g.Plan(
+		arbor.PlannedGroup{Name: "ingredient", Tests: []string{"CreateSuccess","UpdateWithNegativeValue"}},
+		arbor.PlannedGroup{Name: "recipe", After: []string{"ingredient"}, Tests: []string{"UpdateSuccess"}},
+		arbor.PlannedGroup{Name: "order", After: []string{"recipe","ingredient"}, Tests: []string{"RejectsEmptyQuantity"}},
+	)
+
+	g.Define("ingredient", func(at *arbor.T) {
 		g.Append(at, "CreateSuccess", testCreateSuccess)
 		g.Append(at, "UpdateWithNegativeValue", testUpdateWithNegativeValue)
 	})
 
-	t.Run("recipe", func(t *testing.T) {
-		at := arbor.NewT(t)
-		g.Group("recipe")
-		g.After(at, "ingredient")
+	g.Define("recipe", func(at *arbor.T) {
 		g.Append(at, "UpdateSuccess", testUpdateSuccess)
 	})
 
-	t.Run("order", func(t *testing.T) {
-		at := arbor.NewT(t)
-		g.Group("order")
-		g.After(at, "recipe","ingredient")
+	g.Define("order", func(at *arbor.T) {
 		g.Append(at, "RejectsEmptyQuantity", testRejectsEmptyQuantity)
 	})
 
+	g.Run(t)
+
+	g.End()
+
+	t.Log(g.Summary())
+
 	output := g.JSON()
 
 	arbor.Upload(output)
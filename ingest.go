@@ -0,0 +1,99 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/anatollupacescu/arbortest/arbor"
+	"github.com/anatollupacescu/arbortest/report"
+	"github.com/anatollupacescu/arbortest/runner"
+)
+
+// ingest turns the output of go test -json for a TestArbor into a run
+// document, reading the groups the output does not tell about from the tests.
+func ingest(args []string, stdin io.Reader, w io.Writer) error {
+	fs := flag.NewFlagSet("ingest", flag.ContinueOnError)
+	dir := fs.String("dir", ".", "the path to the folder containing the tests")
+	out := fs.String("out", "", "file to write the run document to")
+	url := fs.String("url", "", "arbor server url to upload the run document to")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if fs.NArg() > 1 {
+		return fmt.Errorf("%w: arbortest ingest [-dir ./pkg] [-out run.json] [-url url] [go-test.json]", errUsage)
+	}
+
+	in := stdin
+
+	if name := fs.Arg(0); name != "" && name != "-" {
+		f, err := os.Open(filepath.Clean(name))
+		if err != nil {
+			return err
+		}
+
+		defer func() {
+			_ = f.Close()
+		}()
+
+		in = f
+	}
+
+	tests := testDir(*dir)
+
+	pkg, err := arbor.Inspect(&tests)
+	if err != nil {
+		return fmt.Errorf("inspect %s: %w", *dir, err)
+	}
+
+	g := runner.New()
+	g.Report(failures{w: w})
+
+	for _, grp := range pkg.Groups {
+		g.Plan(runner.PlannedGroup{Name: grp.Name, After: grp.After, Tests: grp.Tests})
+	}
+
+	if err := g.Ingest(in); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(w, g.Summary())
+
+	data := g.JSON()
+
+	if *out != "" {
+		if err := ioutil.WriteFile(*out, []byte(data), 0600); err != nil {
+			return fmt.Errorf("write run document: %w", err)
+		}
+	}
+
+	if *url != "" {
+		return upload(*url, []byte(data))
+	}
+
+	return nil
+}
+
+// failures prints what the tests which failed logged.
+type failures struct {
+	runner.NopReporter
+
+	w io.Writer
+}
+
+func (f failures) TestEnd(r runner.TestResult) {
+	if r.Status != report.StatusFail {
+		return
+	}
+
+	fmt.Fprintf(f.w, "--- FAIL: %s\n", report.TestID(r.Group, r.Name))
+
+	for _, l := range r.Logs {
+		fmt.Fprintf(f.w, "    %s\n", l)
+	}
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/anatollupacescu/arbortest/report"
+)
+
+func TestIngestCommand(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "run.json")
+
+	var printed bytes.Buffer
+
+	err := ingest([]string{"-dir", "./example", "-out", out, "runner/testdata/gotest.json"}, strings.NewReader(""), &printed)
+	assert.NoError(t, err)
+
+	assert.Contains(t, printed.String(), "--- FAIL: recipe/UpdateSuccess\n    p1_test.go:12: no\n")
+	assert.Contains(t, printed.String(), "arbor: 1 passed, 1 failed, 1 skipped because of failed dependencies")
+
+	run, err := readRun(out)
+	assert.NoError(t, err)
+	assert.Len(t, run.Nodes, 7)
+
+	order, _ := node(run, "order")
+	assert.Equal(t, report.StatusSkip, order.Status)
+}
+
+func TestIngestCommandUsage(t *testing.T) {
+	err := ingest([]string{"a.json", "b.json"}, nil, ioutil.Discard)
+
+	assert.EqualError(t, err, "usage: arbortest ingest [-dir ./pkg] [-out run.json] [-url url] [go-test.json]")
+}
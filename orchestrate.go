@@ -0,0 +1,628 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/anatollupacescu/arbortest/arbor"
+	"github.com/anatollupacescu/arbortest/report"
+)
+
+var (
+	errUnknownGroup     = errors.New("group not found")
+	errAmbiguousPackage = errors.New("several packages named")
+	errPackageCycle     = errors.New("circular dependency between packages")
+	errFailed           = errors.New("some groups did not pass")
+)
+
+// pkg is a package declaring arbor groups.
+type pkg struct {
+	arbor.Package
+	importPath string
+	// after lists the names of the packages this one depends on.
+	after []string
+}
+
+// orchestrator runs the TestArbor of several packages, in the order their
+// groups depend on each other, and merges their run documents.
+type orchestrator struct {
+	jobs int
+	// list returns the import paths and directories matching the patterns.
+	list func(patterns []string) ([][2]string, error)
+	// test runs the TestArbor of a package, writing its run document to out.
+	test func(importPath, upstream, out string, w io.Writer) error
+}
+
+// orchestrate is the run command, it prints the output of go test and writes
+// the merged run document.
+func orchestrate(args []string, w io.Writer) error {
+	fs := flag.NewFlagSet("run", flag.ContinueOnError)
+	out := fs.String("out", "run.json", "file to write the merged run document to")
+	url := fs.String("url", "", "arbor server url to upload the merged run document to")
+	jobs := fs.Int("jobs", runtime.NumCPU(), "how many packages to test at once")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	patterns := fs.Args()
+	if len(patterns) == 0 {
+		patterns = []string{"./..."}
+	}
+
+	o := orchestrator{jobs: *jobs, list: goList, test: goTest}
+
+	merged, err := o.run(patterns, w)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(merged)
+	if err != nil {
+		return err
+	}
+
+	if err := ioutil.WriteFile(*out, data, 0600); err != nil {
+		return fmt.Errorf("write run document: %w", err)
+	}
+
+	if *url != "" {
+		if err := upload(*url, data); err != nil {
+			return err
+		}
+	}
+
+	for _, n := range merged.Nodes {
+		if n.Kind == report.KindGroup && n.Status != report.StatusPass {
+			return errFailed
+		}
+	}
+
+	return nil
+}
+
+func (o orchestrator) run(patterns []string, w io.Writer) (report.Run, error) {
+	pkgs, err := o.packages(patterns)
+	if err != nil {
+		return report.Run{}, err
+	}
+
+	if len(pkgs) == 0 {
+		return report.Run{}, fmt.Errorf("%w: no package declares groups", arbor.ErrNoTestsDeclared)
+	}
+
+	tmp, err := ioutil.TempDir("", "arbortest")
+	if err != nil {
+		return report.Run{}, err
+	}
+
+	defer func() {
+		_ = os.RemoveAll(tmp)
+	}()
+
+	if o.jobs < 1 {
+		o.jobs = 1
+	}
+
+	w = &lockedWriter{w: w}
+	locks := newPackageLocks(pkgs)
+
+	var (
+		mu      sync.Mutex
+		runs    = make(map[string]report.Run, len(pkgs))
+		errs    = make(map[string]error)
+		done    = make(map[string]chan struct{}, len(pkgs))
+		running = make(chan struct{}, o.jobs)
+		wg      sync.WaitGroup
+	)
+
+	for _, p := range pkgs {
+		done[p.Name] = make(chan struct{})
+	}
+
+	for _, p := range pkgs {
+		p := p
+
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+			defer close(done[p.Name])
+
+			for _, dep := range p.after {
+				<-done[dep]
+			}
+
+			mu.Lock()
+			upstream, err := merge(runs, p.after)
+			mu.Unlock()
+
+			if err == nil {
+				release := locks.acquire(p)
+				running <- struct{}{}
+				var run report.Run
+				run, err = o.runPackage(p, upstream, tmp, w)
+				<-running
+				release()
+
+				mu.Lock()
+				runs[p.Name] = run
+				mu.Unlock()
+			}
+
+			if err != nil {
+				mu.Lock()
+				errs[p.Name] = err
+				mu.Unlock()
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	names := make([]string, 0, len(pkgs))
+	for _, p := range pkgs {
+		names = append(names, p.Name)
+	}
+
+	for _, name := range names {
+		if err := errs[name]; err != nil {
+			return report.Run{}, fmt.Errorf("package %s: %w", name, err)
+		}
+	}
+
+	return merge(runs, names)
+}
+
+// runPackage tests the package, unless one of the groups of other packages it
+// depends on did not pass, then its groups are reported as skipped.
+func (o orchestrator) runPackage(p pkg, upstream report.Run, tmp string, w io.Writer) (report.Run, error) {
+	var failed string
+
+	for _, g := range p.Groups {
+		for _, dep := range g.External() {
+			if n, ok := node(upstream, dep); failed == "" && (!ok || n.Status != report.StatusPass) {
+				failed = dep
+			}
+		}
+	}
+
+	if failed != "" {
+		fmt.Fprintf(w, "skip\t%s\tafter %s did not pass\n", p.importPath, failed)
+
+		return report.Qualify(skipped(p, upstream, failed), p.Name), nil
+	}
+
+	out := filepath.Join(tmp, p.Name+".json")
+
+	var upstreamFile string
+
+	if len(p.after) > 0 {
+		upstreamFile = filepath.Join(tmp, p.Name+".upstream.json")
+
+		data, err := json.Marshal(upstream)
+		if err != nil {
+			return report.Run{}, err
+		}
+
+		if err := ioutil.WriteFile(upstreamFile, data, 0600); err != nil {
+			return report.Run{}, err
+		}
+	}
+
+	var output bytes.Buffer
+
+	// failing tests make go test fail, the run document tells what happened
+	testErr := o.test(p.importPath, upstreamFile, out, &output)
+
+	_, _ = w.Write(output.Bytes())
+
+	run, err := readRun(out)
+	if err != nil {
+		if testErr != nil {
+			return report.Run{}, fmt.Errorf("%v: %w", testErr, err)
+		}
+
+		return report.Run{}, err
+	}
+
+	return report.Qualify(run, p.Name), nil
+}
+
+// skipped builds the run document of a package which was not tested because
+// the group of another package failed.
+func skipped(p pkg, upstream report.Run, failed string) report.Run {
+	cause := []string{failed}
+
+	if n, ok := node(upstream, failed); ok {
+		switch n.Status {
+		case report.StatusSkip:
+			cause = append(cause, n.SkippedBecause...)
+		case report.StatusFail:
+			for _, t := range upstream.Nodes {
+				if t.Kind == report.KindTest && t.Group == failed && t.Status == report.StatusFail {
+					cause = append(cause, t.ID)
+
+					break
+				}
+			}
+		}
+	}
+
+	run := report.Run{Version: report.Version, Commit: upstream.Commit, Message: upstream.Message}
+
+	for _, g := range p.Groups {
+		run.Nodes = append(run.Nodes, report.Node{
+			ID: g.Name, Label: g.Name, Kind: report.KindGroup, Status: report.StatusSkip, SkippedBecause: cause,
+		})
+
+		for _, t := range g.Tests {
+			id := report.TestID(g.Name, t)
+
+			run.Nodes = append(run.Nodes, report.Node{
+				ID: id, Label: t, Kind: report.KindTest, Group: g.Name, Status: report.StatusSkip,
+				SkippedBecause: append([]string{g.Name}, cause...),
+			})
+			run.Links = append(run.Links, report.Link{Source: id, Target: g.Name, Value: report.TestLink})
+		}
+
+		for _, dep := range g.After {
+			run.Links = append(run.Links, report.Link{Source: g.Name, Target: dep, Value: report.GroupLink})
+		}
+	}
+
+	// the nodes of other packages the links and skips refer to
+	referenced := make(map[string]bool)
+
+	for _, id := range cause {
+		referenced[id] = true
+	}
+
+	for _, l := range run.Links {
+		referenced[l.Target] = true
+	}
+
+	for _, n := range upstream.Nodes {
+		if referenced[n.ID] {
+			run.Nodes = append(run.Nodes, n)
+		}
+	}
+
+	return run
+}
+
+// packages inspects the packages matching the patterns, keeps those with a
+// generated TestArbor and orders them so that packages come after the ones they depend on.
+func (o orchestrator) packages(patterns []string) ([]pkg, error) {
+	dirs, err := o.list(patterns)
+	if err != nil {
+		return nil, err
+	}
+
+	byName := make(map[string]*pkg)
+
+	var pkgs []*pkg
+
+	for _, d := range dirs {
+		dir := testDir(d[1])
+
+		if !dir.generated() {
+			continue
+		}
+
+		p, err := arbor.Inspect(&dir)
+		if errors.Is(err, arbor.ErrNoTestsDeclared) {
+			continue
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("inspect %s: %w", d[0], err)
+		}
+
+		if other, ok := byName[p.Name]; ok {
+			return nil, fmt.Errorf("%w %s: %s and %s", errAmbiguousPackage, p.Name, other.importPath, d[0])
+		}
+
+		byName[p.Name] = &pkg{Package: p, importPath: d[0]}
+		pkgs = append(pkgs, byName[p.Name])
+	}
+
+	for _, p := range pkgs {
+		for _, g := range p.Groups {
+			for _, dep := range g.External() {
+				parts := strings.SplitN(dep, ".", 2)
+
+				other, ok := byName[parts[0]]
+				if !ok || !other.declares(parts[1]) {
+					return nil, fmt.Errorf("%w: %s, after which %s.%s runs", errUnknownGroup, dep, p.Name, g.Name)
+				}
+
+				if !contains(p.after, other.Name) {
+					p.after = append(p.after, other.Name)
+				}
+			}
+		}
+
+		sort.Strings(p.after)
+	}
+
+	return ordered(pkgs, byName)
+}
+
+func (p pkg) declares(group string) bool {
+	for _, g := range p.Groups {
+		if g.Name == group {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ordered sorts the packages so that each comes after its dependencies.
+func ordered(pkgs []*pkg, byName map[string]*pkg) ([]pkg, error) {
+	const (
+		visiting = 1
+		visited  = 2
+	)
+
+	state := make(map[string]int, len(pkgs))
+	order := make([]pkg, 0, len(pkgs))
+
+	var visit func(p *pkg) error
+
+	visit = func(p *pkg) error {
+		switch state[p.Name] {
+		case visiting:
+			return fmt.Errorf("%w: through %s", errPackageCycle, p.Name)
+		case visited:
+			return nil
+		}
+
+		state[p.Name] = visiting
+
+		for _, dep := range p.after {
+			if err := visit(byName[dep]); err != nil {
+				return err
+			}
+		}
+
+		state[p.Name] = visited
+		order = append(order, *p)
+
+		return nil
+	}
+
+	for _, p := range pkgs {
+		if err := visit(p); err != nil {
+			return nil, err
+		}
+	}
+
+	return order, nil
+}
+
+// merge combines the runs of the named packages.
+func merge(runs map[string]report.Run, names []string) (report.Run, error) {
+	parts := make([]report.Run, 0, len(names))
+
+	for _, name := range names {
+		if run, ok := runs[name]; ok {
+			parts = append(parts, run)
+		}
+	}
+
+	if len(parts) == 0 {
+		return report.Run{Version: report.Version}, nil
+	}
+
+	merged, err := report.Merge(parts...)
+	if err != nil {
+		return merged, err
+	}
+
+	// the packages make a single run, not the parts of an uploaded one
+	merged.Received = 0
+
+	return merged, nil
+}
+
+func node(run report.Run, id string) (report.Node, bool) {
+	for _, n := range run.Nodes {
+		if n.ID == id {
+			return n, true
+		}
+	}
+
+	return report.Node{}, false
+}
+
+func contains(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+func goList(patterns []string) ([][2]string, error) {
+	args := append([]string{"list", "-f", "{{.ImportPath}}\t{{.Dir}}"}, patterns...)
+
+	out, err := exec.Command("go", args...).Output()
+	if err != nil {
+		return nil, fmt.Errorf("list packages: %w", err)
+	}
+
+	var dirs [][2]string
+
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		if parts := strings.SplitN(line, "\t", 2); len(parts) == 2 {
+			dirs = append(dirs, [2]string{parts[0], parts[1]})
+		}
+	}
+
+	return dirs, nil
+}
+
+func goTest(importPath, upstream, out string, w io.Writer) error {
+	args := []string{"test", "-count=1", "-run", "^TestArbor$", importPath, "-args", "-arbor.out=" + out}
+	if upstream != "" {
+		args = append(args, "-arbor.upstream="+upstream)
+	}
+
+	cmd := exec.Command("go", args...)
+	cmd.Stdout = w
+	cmd.Stderr = w
+
+	return cmd.Run()
+}
+
+func upload(url string, data []byte) error {
+	resp, err := http.Post(url, "application/json", bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("upload run document: %w", err)
+	}
+
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("upload run document: bad response status: %s", resp.Status)
+	}
+
+	return nil
+}
+
+// packageLocks keeps the packages whose tests hold the same lock from being
+// tested at once, the runner only keeps apart the tests of a single process.
+type packageLocks map[string]*sync.RWMutex
+
+func newPackageLocks(pkgs []pkg) packageLocks {
+	locks := make(packageLocks)
+
+	for _, p := range pkgs {
+		for _, g := range p.Groups {
+			for _, names := range [][]string{g.Locks, g.SharedLocks} {
+				for _, name := range names {
+					if _, ok := locks[name]; !ok {
+						locks[name] = &sync.RWMutex{}
+					}
+				}
+			}
+		}
+	}
+
+	return locks
+}
+
+// acquire takes the locks the tests of the package hold, in the order of
+// their names so that packages never wait for each other in a circle, shared
+// when no test of the package holds them exclusively, and returns the function
+// releasing them.
+func (l packageLocks) acquire(p pkg) func() {
+	exclusive := make(map[string]bool)
+
+	for _, g := range p.Groups {
+		for _, name := range g.SharedLocks {
+			if _, ok := exclusive[name]; !ok {
+				exclusive[name] = false
+			}
+		}
+
+		for _, name := range g.Locks {
+			exclusive[name] = true
+		}
+	}
+
+	names := make([]string, 0, len(exclusive))
+	for name := range exclusive {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		if exclusive[name] {
+			l[name].Lock()
+		} else {
+			l[name].RLock()
+		}
+	}
+
+	return func() {
+		for _, name := range names {
+			if exclusive[name] {
+				l[name].Unlock()
+			} else {
+				l[name].RUnlock()
+			}
+		}
+	}
+}
+
+// lockedWriter lets the packages tested at once print their output, one write at a time.
+type lockedWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (l *lockedWriter) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.w.Write(p)
+}
+
+// testDir lists the test files of a directory, for arbor to inspect.
+type testDir string
+
+func (d *testDir) List() []arbor.File {
+	matches, _ := filepath.Glob(filepath.Join(string(*d), "*_test.go"))
+
+	files := make([]arbor.File, 0, len(matches))
+
+	for _, m := range matches {
+		f := testFile(m)
+		files = append(files, &f)
+	}
+
+	return files
+}
+
+// generated tells whether arborgen generated the TestArbor of the package.
+func (d *testDir) generated() bool {
+	for _, f := range d.List() {
+		if strings.Contains(f.Read(), "\nfunc TestArbor(") {
+			return true
+		}
+	}
+
+	return false
+}
+
+type testFile string
+
+func (f *testFile) Read() string {
+	data, err := ioutil.ReadFile(filepath.Clean(string(*f)))
+	if err != nil {
+		return ""
+	}
+
+	return string(data)
+}
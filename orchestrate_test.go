@@ -0,0 +1,212 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/anatollupacescu/arbortest/report"
+)
+
+const (
+	inventoryTests = `package inventory_test
+
+import "github.com/anatollupacescu/arbortest/runner"
+
+// group:ingredient
+func testCreate(t *runner.T) {}
+`
+
+	shopTests = `package shop
+
+import "github.com/anatollupacescu/arbortest/runner"
+
+// group:order after:inventory.ingredient
+func testPlace(t *runner.T) {}
+`
+)
+
+const generated = `package generated
+
+import "testing"
+
+func TestArbor(t *testing.T) {}
+`
+
+// fakeRepo writes the test files of the packages and returns an orchestrator
+// whose go test writes the given run documents instead of running anything.
+func fakeRepo(t *testing.T, sources, results map[string]string) (orchestrator, map[string]string) {
+	root := t.TempDir()
+	upstreams := make(map[string]string)
+
+	var dirs [][2]string
+
+	for _, name := range []string{"inventory", "shop"} {
+		dir := filepath.Join(root, name)
+		assert.NoError(t, os.Mkdir(dir, 0700))
+		assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "a_test.go"), []byte(sources[name]), 0600))
+		assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "generated_test.go"), []byte(generated), 0600))
+
+		dirs = append(dirs, [2]string{"example.com/" + name, dir})
+	}
+
+	o := orchestrator{
+		jobs: 2,
+		list: func([]string) ([][2]string, error) {
+			return dirs, nil
+		},
+		test: func(importPath, upstream, out string, w io.Writer) error {
+			name := filepath.Base(importPath)
+
+			if upstream != "" {
+				data, err := ioutil.ReadFile(upstream)
+				assert.NoError(t, err)
+
+				upstreams[name] = string(data)
+			}
+
+			return ioutil.WriteFile(out, []byte(results[name]), 0600)
+		},
+	}
+
+	return o, upstreams
+}
+
+func TestRunOrdersPackages(t *testing.T) {
+	o, upstreams := fakeRepo(t, map[string]string{"inventory": inventoryTests, "shop": shopTests}, map[string]string{
+		"inventory": `{"version":2,"commit":"abc","nodes":[{"id":"ingredient","kind":"group","status":"pass"}]}`,
+		"shop": `{"version":2,"commit":"abc","nodes":[{"id":"order","kind":"group","status":"pass"},` +
+			`{"id":"inventory.ingredient","label":"inventory.ingredient","kind":"group","status":"pass"}],` +
+			`"links":[{"source":"order","target":"inventory.ingredient","value":3}]}`,
+	})
+
+	run, err := o.run(nil, ioutil.Discard)
+	assert.NoError(t, err)
+
+	assert.Contains(t, upstreams["shop"], `"id":"inventory.ingredient"`, "shop is told how inventory went")
+	assert.Equal(t, []report.Node{
+		{ID: "inventory.ingredient", Label: "inventory.ingredient", Kind: report.KindGroup, Status: report.StatusPass},
+		{ID: "shop.order", Label: "shop.order", Kind: report.KindGroup, Status: report.StatusPass},
+	}, run.Nodes)
+	assert.Equal(t, []report.Link{{Source: "shop.order", Target: "inventory.ingredient", Value: report.GroupLink}}, run.Links)
+	assert.NoError(t, run.Validate())
+}
+
+func TestRunSkipsPackagesAfterFailedGroups(t *testing.T) {
+	o, upstreams := fakeRepo(t, map[string]string{"inventory": inventoryTests, "shop": shopTests}, map[string]string{
+		"inventory": `{"version":2,"commit":"abc","nodes":[{"id":"ingredient","kind":"group","status":"fail"},` +
+			`{"id":"ingredient/Create","label":"Create","kind":"test","group":"ingredient","status":"fail"}],` +
+			`"links":[{"source":"ingredient/Create","target":"ingredient","value":1}]}`,
+	})
+
+	var out bytes.Buffer
+
+	run, err := o.run(nil, &out)
+	assert.NoError(t, err)
+
+	assert.Empty(t, upstreams, "shop is not tested")
+	assert.Contains(t, out.String(), "skip\texample.com/shop\tafter inventory.ingredient did not pass")
+
+	order, _ := node(run, "shop.order")
+	assert.Equal(t, report.StatusSkip, order.Status)
+	assert.Equal(t, []string{"inventory.ingredient", "inventory.ingredient/Create"}, order.SkippedBecause)
+
+	place, _ := node(run, "shop.order/Place")
+	assert.Equal(t, []string{"shop.order", "inventory.ingredient", "inventory.ingredient/Create"}, place.SkippedBecause)
+	assert.NoError(t, run.Validate())
+}
+
+func TestRunSerialisesPackagesHoldingTheSameLock(t *testing.T) {
+	o, _ := fakeRepo(t, map[string]string{
+		"inventory": `package inventory
+
+import "github.com/anatollupacescu/arbortest/runner"
+
+// group:ingredient lock:db
+func testCreate(t *runner.T) {}
+`,
+		"shop": `package shop
+
+import "github.com/anatollupacescu/arbortest/runner"
+
+// group:order rlock:db
+func testPlace(t *runner.T) {}
+`,
+	}, map[string]string{
+		"inventory": `{"version":2,"commit":"abc","nodes":[{"id":"ingredient","kind":"group","status":"pass"}]}`,
+		"shop":      `{"version":2,"commit":"abc","nodes":[{"id":"order","kind":"group","status":"pass"}]}`,
+	})
+
+	var running, most int32
+
+	test := o.test
+	o.test = func(importPath, upstream, out string, w io.Writer) error {
+		n := atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+
+		for {
+			m := atomic.LoadInt32(&most)
+			if n <= m || atomic.CompareAndSwapInt32(&most, m, n) {
+				break
+			}
+		}
+
+		time.Sleep(20 * time.Millisecond)
+
+		return test(importPath, upstream, out, w)
+	}
+
+	_, err := o.run(nil, ioutil.Discard)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&most), "the packages do not share db")
+}
+
+func TestRunRejectsUnknownGroups(t *testing.T) {
+	o, _ := fakeRepo(t, map[string]string{
+		"inventory": inventoryTests,
+		"shop": `package shop
+
+import "github.com/anatollupacescu/arbortest/runner"
+
+// group:order after:inventory.stock
+func testPlace(t *runner.T) {}
+`,
+	}, nil)
+
+	_, err := o.run(nil, ioutil.Discard)
+	assert.EqualError(t, err, "group not found: inventory.stock, after which shop.order runs")
+}
+
+func TestRunRejectsPackageCycles(t *testing.T) {
+	o, _ := fakeRepo(t, map[string]string{
+		"inventory": `package inventory
+
+import "github.com/anatollupacescu/arbortest/runner"
+
+// group:ingredient after:shop.order
+func testCreate(t *runner.T) {}
+`,
+		"shop": shopTests,
+	}, nil)
+
+	_, err := o.run(nil, ioutil.Discard)
+	assert.EqualError(t, err, "circular dependency between packages: through inventory")
+}
+
+func TestRunNamesMalformedPackages(t *testing.T) {
+	o, _ := fakeRepo(t, map[string]string{
+		"inventory": inventoryTests,
+		"shop":      "package shop\n\nfunc testPlace(",
+	}, nil)
+
+	_, err := o.run(nil, ioutil.Discard)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "inspect example.com/shop: parse file: ")
+}
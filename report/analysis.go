@@ -0,0 +1,177 @@
+package report
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// ErrCycle returned when the group dependencies of a run form a cycle.
+var ErrCycle = errors.New("dependency cycle")
+
+// Analysis tells how long a run would take if its groups ran as soon as
+// their dependencies passed, with as many of them in parallel as possible.
+type Analysis struct {
+	// Sequential is the time the groups took one after the other.
+	Sequential time.Duration `json:"sequential"`
+	// Minimum is the shortest possible wall time with unlimited parallelism,
+	// the duration of the critical path.
+	Minimum time.Duration `json:"minimum"`
+	// CriticalPath lists the groups, from the first to run to the last to end,
+	// which would have to get faster for the run to get shorter.
+	CriticalPath []string `json:"criticalPath"`
+	// Groups are listed in the order they could start.
+	Groups []Schedule `json:"groups"`
+}
+
+// Schedule is the place of a group in the fastest possible run.
+type Schedule struct {
+	ID       string        `json:"id"`
+	Duration time.Duration `json:"duration"`
+	Start    time.Duration `json:"start"`
+	// Slack is how much longer the group could take without delaying the run.
+	Slack time.Duration `json:"slack"`
+}
+
+// Analyze computes the critical path of the run, groups take the time they
+// took in the run and their tests run one after the other.
+func Analyze(r Run) (Analysis, error) {
+	var (
+		groups     []Node
+		deps       = make(map[string][]string)
+		dependents = make(map[string][]string)
+	)
+
+	ids := make(map[string]Node)
+
+	for _, n := range r.Nodes {
+		if n.Kind == KindGroup {
+			groups = append(groups, n)
+			ids[n.ID] = n
+		}
+	}
+
+	for _, l := range r.Links {
+		_, from := ids[l.Source]
+		_, to := ids[l.Target]
+
+		if l.Value == GroupLink && from && to {
+			deps[l.Source] = append(deps[l.Source], l.Target)
+			dependents[l.Target] = append(dependents[l.Target], l.Source)
+		}
+	}
+
+	order, err := topological(groups, deps)
+	if err != nil {
+		return Analysis{}, err
+	}
+
+	a := Analysis{CriticalPath: make([]string, 0), Groups: make([]Schedule, 0, len(order))}
+
+	start := make(map[string]time.Duration, len(order))
+	end := make(map[string]time.Duration, len(order))
+	// via is the dependency ending last, which the group waits for
+	via := make(map[string]string)
+
+	var last string
+
+	for _, id := range order {
+		for _, dep := range deps[id] {
+			if end[dep] > start[id] || via[id] == "" {
+				start[id] = end[dep]
+				via[id] = dep
+			}
+		}
+
+		end[id] = start[id] + ids[id].Duration
+		a.Sequential += ids[id].Duration
+
+		if last == "" || end[id] > end[last] {
+			last = id
+		}
+	}
+
+	if last == "" {
+		return a, nil
+	}
+
+	a.Minimum = end[last]
+
+	for id := last; id != ""; id = via[id] {
+		a.CriticalPath = append([]string{id}, a.CriticalPath...)
+	}
+
+	// the latest a group may end is the earliest any of its dependents has to start
+	latest := make(map[string]time.Duration, len(order))
+
+	for i := len(order) - 1; i >= 0; i-- {
+		id := order[i]
+		latest[id] = a.Minimum
+
+		for _, d := range dependents[id] {
+			if s := latest[d] - ids[d].Duration; s < latest[id] {
+				latest[id] = s
+			}
+		}
+	}
+
+	for _, id := range order {
+		a.Groups = append(a.Groups, Schedule{
+			ID:       id,
+			Duration: ids[id].Duration,
+			Start:    start[id],
+			Slack:    latest[id] - end[id],
+		})
+	}
+
+	sort.SliceStable(a.Groups, func(i, j int) bool {
+		return a.Groups[i].Start < a.Groups[j].Start
+	})
+
+	return a, nil
+}
+
+// topological orders the groups so that each comes after its dependencies,
+// keeping the order of the run where it can.
+func topological(groups []Node, deps map[string][]string) ([]string, error) {
+	const (
+		visiting = iota + 1
+		visited
+	)
+
+	state := make(map[string]int, len(groups))
+	order := make([]string, 0, len(groups))
+
+	var visit func(id string) error
+
+	visit = func(id string) error {
+		switch state[id] {
+		case visiting:
+			return fmt.Errorf("%w: through %q", ErrCycle, id)
+		case visited:
+			return nil
+		}
+
+		state[id] = visiting
+
+		for _, dep := range deps[id] {
+			if err := visit(dep); err != nil {
+				return err
+			}
+		}
+
+		state[id] = visited
+		order = append(order, id)
+
+		return nil
+	}
+
+	for _, g := range groups {
+		if err := visit(g.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	return order, nil
+}
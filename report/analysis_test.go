@@ -0,0 +1,57 @@
+package report_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/anatollupacescu/arbortest/report"
+)
+
+func group(id string, ms int) report.Node {
+	return report.Node{ID: id, Kind: report.KindGroup, Status: report.StatusPass, Duration: time.Duration(ms) * time.Millisecond}
+}
+
+func after(source, target string) report.Link {
+	return report.Link{Source: source, Target: target, Value: report.GroupLink}
+}
+
+func TestAnalyze(t *testing.T) {
+	run := report.Run{
+		Nodes: []report.Node{
+			group("a", 100), group("b", 300), group("c", 100), group("d", 50), group("e", 200),
+			{ID: "a/test", Kind: report.KindTest, Group: "a", Status: report.StatusPass, Duration: 100 * time.Millisecond},
+		},
+		Links: []report.Link{
+			{Source: "a/test", Target: "a", Value: report.TestLink},
+			after("b", "a"), after("c", "a"), after("d", "b"), after("d", "c"),
+		},
+	}
+
+	a, err := report.Analyze(run)
+	assert.NoError(t, err)
+
+	ms := time.Millisecond
+
+	assert.Equal(t, 750*ms, a.Sequential)
+	assert.Equal(t, 450*ms, a.Minimum)
+	assert.Equal(t, []string{"a", "b", "d"}, a.CriticalPath)
+	assert.Equal(t, []report.Schedule{
+		{ID: "a", Duration: 100 * ms, Start: 0, Slack: 0},
+		{ID: "e", Duration: 200 * ms, Start: 0, Slack: 250 * ms},
+		{ID: "b", Duration: 300 * ms, Start: 100 * ms, Slack: 0},
+		{ID: "c", Duration: 100 * ms, Start: 100 * ms, Slack: 200 * ms},
+		{ID: "d", Duration: 50 * ms, Start: 400 * ms, Slack: 0},
+	}, a.Groups)
+}
+
+func TestAnalyzeCycle(t *testing.T) {
+	run := report.Run{
+		Nodes: []report.Node{group("a", 1), group("b", 1)},
+		Links: []report.Link{after("a", "b"), after("b", "a")},
+	}
+
+	_, err := report.Analyze(run)
+	assert.EqualError(t, err, `dependency cycle: through "a"`)
+}
@@ -0,0 +1,132 @@
+package report
+
+import "time"
+
+// A node regressed when it took regressionFactor times longer than in the base
+// run, differences below regressionFloor are considered noise.
+const (
+	regressionFactor = 1.5
+	regressionFloor  = 10 * time.Millisecond
+)
+
+// Diff lists the changes between two runs.
+type Diff struct {
+	// Added nodes are present only in the head run.
+	Added []string `json:"added"`
+	// Removed nodes are present only in the base run.
+	Removed []string `json:"removed"`
+	// Transitions are nodes that changed status, e.g. from pass to fail.
+	Transitions []Transition `json:"transitions"`
+	// Skipped are the groups at the root of subtrees which were not skipped in the base run.
+	Skipped []string `json:"skipped"`
+	// Regressions are nodes which took noticeably longer than in the base run.
+	Regressions []Regression `json:"regressions"`
+}
+
+// Transition is a change of status of a node present in both runs.
+type Transition struct {
+	ID   string `json:"id"`
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// Regression is a node which got slower between runs.
+type Regression struct {
+	ID   string        `json:"id"`
+	Base time.Duration `json:"base"`
+	Head time.Duration `json:"head"`
+}
+
+// Compare computes the changes needed to get from the base run to the head one.
+func Compare(base, head Run) Diff {
+	d := Diff{
+		Added:       make([]string, 0),
+		Removed:     make([]string, 0),
+		Transitions: make([]Transition, 0),
+		Skipped:     make([]string, 0),
+		Regressions: make([]Regression, 0),
+	}
+
+	baseNodes := base.nodesByID()
+	headNodes := head.nodesByID()
+
+	for _, n := range base.Nodes {
+		if _, ok := headNodes[n.ID]; !ok {
+			d.Removed = append(d.Removed, n.ID)
+		}
+	}
+
+	newlySkipped := make(map[string]struct{})
+
+	for _, n := range head.Nodes {
+		was, ok := baseNodes[n.ID]
+		if !ok {
+			d.Added = append(d.Added, n.ID)
+			continue
+		}
+
+		if was.Status != n.Status {
+			d.Transitions = append(d.Transitions, Transition{ID: n.ID, From: was.Status, To: n.Status})
+
+			if n.Status == StatusSkip {
+				newlySkipped[n.ID] = struct{}{}
+			}
+		}
+
+		if isRegression(was.Duration, n.Duration) {
+			d.Regressions = append(d.Regressions, Regression{ID: n.ID, Base: was.Duration, Head: n.Duration})
+		}
+	}
+
+	d.Skipped = head.skippedRoots(newlySkipped)
+
+	return d
+}
+
+func isRegression(base, head time.Duration) bool {
+	if head-base < regressionFloor {
+		return false
+	}
+
+	return float64(head) > float64(base)*regressionFactor
+}
+
+func (r Run) nodesByID() map[string]Node {
+	nodes := make(map[string]Node, len(r.Nodes))
+	for _, n := range r.Nodes {
+		nodes[n.ID] = n
+	}
+
+	return nodes
+}
+
+// skippedRoots keeps the groups whose own dependencies are not in the skipped set.
+func (r Run) skippedRoots(skipped map[string]struct{}) []string {
+	roots := make([]string, 0)
+
+	for _, n := range r.Nodes {
+		if _, ok := skipped[n.ID]; !ok || n.Kind != KindGroup {
+			continue
+		}
+
+		if !r.dependsOnAny(n.ID, skipped) {
+			roots = append(roots, n.ID)
+		}
+	}
+
+	return roots
+}
+
+func (r Run) dependsOnAny(id string, set map[string]struct{}) bool {
+	for _, l := range r.Links {
+		if l.Value != GroupLink || l.Source != id {
+			continue
+		}
+
+		if _, ok := set[l.Target]; ok {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,115 @@
+package report_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/anatollupacescu/arbortest/report"
+)
+
+func TestCompareIdenticalRuns(t *testing.T) {
+	run := report.Run{
+		Nodes: []report.Node{
+			{ID: "group", Status: report.StatusPass},
+			{ID: "test", Status: report.StatusPass},
+		},
+		Links: []report.Link{{Source: "test", Target: "group", Value: report.TestLink}},
+	}
+
+	d := report.Compare(run, run)
+
+	assert.Empty(t, d.Added)
+	assert.Empty(t, d.Removed)
+	assert.Empty(t, d.Transitions)
+	assert.Empty(t, d.Skipped)
+	assert.Empty(t, d.Regressions)
+}
+
+func TestCompareAddedAndRemoved(t *testing.T) {
+	base := report.Run{
+		Nodes: []report.Node{
+			{ID: "group", Status: report.StatusPass},
+			{ID: "old", Status: report.StatusPass},
+		},
+	}
+	head := report.Run{
+		Nodes: []report.Node{
+			{ID: "group", Status: report.StatusPass},
+			{ID: "new", Status: report.StatusPass},
+		},
+	}
+
+	d := report.Compare(base, head)
+
+	assert.Equal(t, []string{"new"}, d.Added)
+	assert.Equal(t, []string{"old"}, d.Removed)
+}
+
+func TestCompareTransitions(t *testing.T) {
+	base := report.Run{
+		Nodes: []report.Node{
+			{ID: "a", Kind: report.KindGroup, Status: report.StatusPass},
+			{ID: "a/1", Kind: report.KindTest, Group: "a", Status: report.StatusPass},
+			{ID: "b", Kind: report.KindGroup, Status: report.StatusFail},
+			{ID: "b/1", Kind: report.KindTest, Group: "b", Status: report.StatusFail},
+			{ID: "c", Kind: report.KindGroup, Status: report.StatusPass},
+			{ID: "d", Kind: report.KindGroup, Status: report.StatusPass},
+		},
+		Links: []report.Link{
+			{Source: "a/1", Target: "a", Value: report.TestLink},
+			{Source: "b/1", Target: "b", Value: report.TestLink},
+			{Source: "c", Target: "a", Value: report.GroupLink},
+			{Source: "d", Target: "c", Value: report.GroupLink},
+		},
+	}
+	head := report.Run{
+		Nodes: []report.Node{
+			{ID: "a", Kind: report.KindGroup, Status: report.StatusFail},
+			{ID: "a/1", Kind: report.KindTest, Group: "a", Status: report.StatusFail},
+			{ID: "b", Kind: report.KindGroup, Status: report.StatusPass},
+			{ID: "b/1", Kind: report.KindTest, Group: "b", Status: report.StatusPass},
+			{ID: "c", Kind: report.KindGroup, Status: report.StatusSkip},
+			{ID: "d", Kind: report.KindGroup, Status: report.StatusSkip},
+		},
+		Links: base.Links,
+	}
+
+	d := report.Compare(base, head)
+
+	expected := []report.Transition{
+		{ID: "a", From: report.StatusPass, To: report.StatusFail},
+		{ID: "a/1", From: report.StatusPass, To: report.StatusFail},
+		{ID: "b", From: report.StatusFail, To: report.StatusPass},
+		{ID: "b/1", From: report.StatusFail, To: report.StatusPass},
+		{ID: "c", From: report.StatusPass, To: report.StatusSkip},
+		{ID: "d", From: report.StatusPass, To: report.StatusSkip},
+	}
+	assert.Equal(t, expected, d.Transitions)
+	assert.Equal(t, []string{"c"}, d.Skipped)
+}
+
+func TestCompareRegressions(t *testing.T) {
+	base := report.Run{
+		Nodes: []report.Node{
+			{ID: "slow", Status: report.StatusPass, Duration: 100 * time.Millisecond},
+			{ID: "noise", Status: report.StatusPass, Duration: time.Millisecond},
+			{ID: "steady", Status: report.StatusPass, Duration: time.Second},
+		},
+	}
+	head := report.Run{
+		Nodes: []report.Node{
+			{ID: "slow", Status: report.StatusPass, Duration: 300 * time.Millisecond},
+			{ID: "noise", Status: report.StatusPass, Duration: 5 * time.Millisecond},
+			{ID: "steady", Status: report.StatusPass, Duration: 1100 * time.Millisecond},
+		},
+	}
+
+	d := report.Compare(base, head)
+
+	expected := []report.Regression{
+		{ID: "slow", Base: 100 * time.Millisecond, Head: 300 * time.Millisecond},
+	}
+	assert.Equal(t, expected, d.Regressions)
+}
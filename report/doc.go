@@ -0,0 +1,3 @@
+// Package report defines the run document produced by the 'runner' package
+// and accepted, stored and broadcast by the UI server
+package report
@@ -0,0 +1,194 @@
+package report
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+var (
+	// ErrConflict returned when parts of a run disagree on a node or on the commit.
+	ErrConflict = errors.New("conflicting parts")
+	// ErrNoParts returned when there is nothing to merge.
+	ErrNoParts = errors.New("no parts")
+)
+
+// Merge combines the parts of a run into one document. Nodes present in
+// several parts must agree, except for their durations, unless exactly one
+// of the parts is the shard responsible for the group, whose version is kept.
+func Merge(parts ...Run) (Run, error) {
+	if len(parts) == 0 {
+		return Run{}, ErrNoParts
+	}
+
+	merged := Run{
+		Version:  Version,
+		Commit:   parts[0].Commit,
+		Message:  parts[0].Message,
+		Nodes:    make([]Node, 0),
+		Links:    make([]Link, 0),
+		ID:       parts[0].ID,
+		Received: len(parts),
+	}
+
+	// index of the node in merged.Nodes and the part it was taken from
+	index := make(map[string]int)
+	from := make(map[string]int)
+	links := make(map[Link]struct{})
+
+	for p, part := range parts {
+		if part.ID != merged.ID {
+			return Run{}, fmt.Errorf("%w: run %q and %q", ErrConflict, merged.ID, part.ID)
+		}
+
+		if known(part.Commit) && known(merged.Commit) && part.Commit != merged.Commit {
+			return Run{}, fmt.Errorf("%w: commit %q and %q", ErrConflict, merged.Commit, part.Commit)
+		}
+
+		if !known(merged.Commit) {
+			merged.Commit, merged.Message = part.Commit, part.Message
+		}
+
+		if expected := part.expected(); expected > merged.Parts {
+			merged.Parts = expected
+		}
+
+		for _, n := range part.Nodes {
+			i, ok := index[n.ID]
+			if !ok {
+				index[n.ID] = len(merged.Nodes)
+				from[n.ID] = p
+				merged.Nodes = append(merged.Nodes, n)
+
+				continue
+			}
+
+			kept := merged.Nodes[i]
+			if same(kept, n) {
+				continue
+			}
+
+			keptOwns, owns := parts[from[n.ID]].owns(kept), part.owns(n)
+
+			switch {
+			case kept.Kind != n.Kind || keptOwns == owns:
+				return Run{}, fmt.Errorf("%w: node %q is %s in one part and %s in another", ErrConflict, n.ID, kept.Status, n.Status)
+			case owns:
+				merged.Nodes[i] = n
+				from[n.ID] = p
+			}
+		}
+
+		for _, l := range part.Links {
+			if _, ok := links[l]; !ok {
+				links[l] = struct{}{}
+				merged.Links = append(merged.Links, l)
+			}
+		}
+	}
+
+	merged.Complete = merged.Parts > 0 && merged.Received >= merged.Parts
+
+	return merged, nil
+}
+
+// expected tells how many parts the run is made of, as far as this part knows.
+func (r Run) expected() int {
+	if r.Parts == 0 && r.Shard != nil {
+		return r.Shard.Total
+	}
+
+	return r.Parts
+}
+
+// PartID identifies the part within the run, shards are told apart by their
+// index unless named otherwise. It is empty for parts which can not be told
+// apart, each of them counts as a part of its own.
+func (r Run) PartID() string {
+	switch {
+	case r.Part != "":
+		return r.Part
+	case r.Shard != nil:
+		return fmt.Sprintf("shard %d/%d", r.Shard.Index, r.Shard.Total)
+	default:
+		return ""
+	}
+}
+
+// owns tells whether the run is the shard responsible for the node.
+func (r Run) owns(n Node) bool {
+	if r.Shard == nil {
+		return false
+	}
+
+	group := n.ID
+	if n.Kind == KindTest {
+		group = n.Group
+	}
+
+	for _, g := range r.Shard.Groups {
+		if g == group {
+			return true
+		}
+	}
+
+	return false
+}
+
+func same(a, b Node) bool {
+	a.Duration, b.Duration = 0, 0
+
+	return reflect.DeepEqual(a, b)
+}
+
+func known(commit string) bool {
+	return commit != "" && commit != unknown
+}
+
+// Qualify prefixes the ids of the groups of a package's run with the package
+// name, so that runs of several packages can be merged. Ids already
+// qualified, those of the groups of other packages, are left as they are.
+func Qualify(run Run, pkg string) Run {
+	q := func(id string) string {
+		if strings.Contains(strings.SplitN(id, "/", 2)[0], ".") {
+			return id
+		}
+
+		return pkg + "." + id
+	}
+
+	qualified := run
+	qualified.Shard = nil
+	qualified.Nodes = make([]Node, 0, len(run.Nodes))
+	qualified.Links = make([]Link, 0, len(run.Links))
+
+	for _, n := range run.Nodes {
+		if n.Kind == KindGroup && n.ID != q(n.ID) {
+			n.Label = q(n.Label)
+		}
+
+		n.ID = q(n.ID)
+
+		if n.Group != "" {
+			n.Group = q(n.Group)
+		}
+
+		because := make([]string, 0, len(n.SkippedBecause))
+		for _, id := range n.SkippedBecause {
+			because = append(because, q(id))
+		}
+
+		if len(because) > 0 {
+			n.SkippedBecause = because
+		}
+
+		qualified.Nodes = append(qualified.Nodes, n)
+	}
+
+	for _, l := range run.Links {
+		qualified.Links = append(qualified.Links, Link{Source: q(l.Source), Target: q(l.Target), Value: l.Value})
+	}
+
+	return qualified
+}
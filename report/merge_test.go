@@ -0,0 +1,105 @@
+package report_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/anatollupacescu/arbortest/report"
+)
+
+func shard(index, total int, owned []string, nodes ...report.Node) report.Run {
+	return report.Run{
+		Version: report.Version,
+		Commit:  "abc",
+		ID:      "ci-42",
+		Nodes:   nodes,
+		Shard:   &report.Shard{Index: index, Total: total, Groups: owned},
+	}
+}
+
+func TestMergeShards(t *testing.T) {
+	root := group("root", 10)
+	flakyRoot := root
+	flakyRoot.Status = report.StatusFail
+
+	first := shard(1, 2, []string{"root", "x"}, root, group("x", 20))
+	first.Links = []report.Link{after("x", "root")}
+
+	second := shard(2, 2, []string{"y"}, flakyRoot, group("y", 30))
+	second.Links = []report.Link{after("y", "root")}
+
+	merged, err := report.Merge(first)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, merged.Parts)
+	assert.Equal(t, 1, merged.Received)
+	assert.False(t, merged.Complete)
+
+	merged, err = report.Merge(second, first)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "ci-42", merged.ID)
+	assert.Equal(t, "abc", merged.Commit)
+	assert.Equal(t, []report.Node{root, group("y", 30), group("x", 20)}, merged.Nodes, "the responsible shard wins")
+	assert.Equal(t, []report.Link{after("y", "root"), after("x", "root")}, merged.Links)
+	assert.Nil(t, merged.Shard)
+	assert.True(t, merged.Complete)
+	assert.NoError(t, merged.Validate())
+}
+
+func TestMergeConflicts(t *testing.T) {
+	failed := group("a", 10)
+	failed.Status = report.StatusFail
+
+	pkg := func(commit string, nodes ...report.Node) report.Run {
+		return report.Run{Version: report.Version, Commit: commit, ID: "ci-42", Nodes: nodes}
+	}
+
+	_, err := report.Merge(pkg("abc", group("a", 10)), pkg("abc", failed))
+	assert.EqualError(t, err, `conflicting parts: node "a" is pass in one part and fail in another`)
+
+	_, err = report.Merge(pkg("abc", group("a", 10)), pkg("def", group("b", 10)))
+	assert.EqualError(t, err, `conflicting parts: commit "abc" and "def"`)
+
+	other := pkg("abc", group("b", 10))
+	other.ID = "ci-43"
+
+	_, err = report.Merge(pkg("abc", group("a", 10)), other)
+	assert.EqualError(t, err, `conflicting parts: run "ci-42" and "ci-43"`)
+
+	_, err = report.Merge()
+	assert.EqualError(t, err, "no parts")
+}
+
+func TestQualify(t *testing.T) {
+	run := report.Run{
+		Version: report.Version,
+		Nodes: []report.Node{
+			{ID: "order", Label: "order", Kind: report.KindGroup, Status: report.StatusSkip,
+				SkippedBecause: []string{"inventory.ingredient"}},
+			{ID: "order/Place", Label: "Place", Kind: report.KindTest, Group: "order", Status: report.StatusSkip,
+				SkippedBecause: []string{"order", "inventory.ingredient"}},
+			{ID: "inventory.ingredient", Label: "inventory.ingredient", Kind: report.KindGroup, Status: report.StatusFail},
+		},
+		Links: []report.Link{
+			{Source: "order/Place", Target: "order", Value: report.TestLink},
+			{Source: "order", Target: "inventory.ingredient", Value: report.GroupLink},
+		},
+	}
+
+	qualified := report.Qualify(run, "shop")
+
+	assert.Equal(t, []report.Node{
+		{ID: "shop.order", Label: "shop.order", Kind: report.KindGroup, Status: report.StatusSkip,
+			SkippedBecause: []string{"inventory.ingredient"}},
+		{ID: "shop.order/Place", Label: "Place", Kind: report.KindTest, Group: "shop.order", Status: report.StatusSkip,
+			SkippedBecause: []string{"shop.order", "inventory.ingredient"}},
+		{ID: "inventory.ingredient", Label: "inventory.ingredient", Kind: report.KindGroup, Status: report.StatusFail},
+	}, qualified.Nodes)
+	assert.Equal(t, []report.Link{
+		{Source: "shop.order/Place", Target: "shop.order", Value: report.TestLink},
+		{Source: "shop.order", Target: "inventory.ingredient", Value: report.GroupLink},
+	}, qualified.Links)
+	assert.NoError(t, qualified.Validate())
+	assert.Equal(t, "order", run.Nodes[0].ID, "the run is left untouched")
+}
@@ -0,0 +1,81 @@
+package report
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Statuses a node can only be reported with while the run is in progress.
+const (
+	StatusPending = "pending"
+	StatusRunning = "running"
+)
+
+// Progress events, in the order a run sends them.
+const (
+	ProgressStart  = "start"
+	ProgressUpdate = "update"
+	ProgressEnd    = "end"
+)
+
+// ErrUnknownEvent returned when a progress event is not listed in this package.
+var ErrUnknownEvent = errors.New("unknown event")
+
+// Progress is sent while a run is going on, it carries the nodes which changed
+// since the previous event of the same run and the links they bring along.
+type Progress struct {
+	// Run tells apart the events of runs going on at the same time.
+	Run   string `json:"run"`
+	Event string `json:"event"`
+	Nodes []Node `json:"nodes,omitempty"`
+	Links []Link `json:"links,omitempty"`
+}
+
+// DecodeProgress reads a progress event and validates it.
+func DecodeProgress(r io.Reader) (Progress, error) {
+	var p Progress
+
+	if err := json.NewDecoder(r).Decode(&p); err != nil {
+		return p, fmt.Errorf("decode progress event: %w", err)
+	}
+
+	if err := p.Validate(); err != nil {
+		return p, err
+	}
+
+	return p, nil
+}
+
+// Validate checks the event is well formed, links may reference nodes sent
+// by earlier events so only their own fields are checked.
+func (p Progress) Validate() error {
+	if p.Run == "" {
+		return fmt.Errorf("%w: run", ErrMissingField)
+	}
+
+	switch p.Event {
+	case ProgressStart, ProgressUpdate, ProgressEnd:
+	default:
+		return fmt.Errorf("%w: %q", ErrUnknownEvent, p.Event)
+	}
+
+	for i, n := range p.Nodes {
+		if err := n.validate(i, liveStatus); err != nil {
+			return err
+		}
+	}
+
+	for i, l := range p.Links {
+		if err := l.validate(i); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func liveStatus(s string) bool {
+	return s == StatusPending || s == StatusRunning || knownStatus(s)
+}
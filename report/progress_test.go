@@ -0,0 +1,69 @@
+package report_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/anatollupacescu/arbortest/report"
+)
+
+func TestDecodeProgress(t *testing.T) {
+	tt := []struct {
+		name string
+		json string
+		err  string
+	}{
+		{
+			name: "malformed json",
+			json: `{"run":`,
+			err:  "decode progress event: unexpected EOF",
+		}, {
+			name: "no run",
+			json: `{"event":"start"}`,
+			err:  "missing field: run",
+		}, {
+			name: "unknown event",
+			json: `{"run":"r","event":"pause"}`,
+			err:  `unknown event: "pause"`,
+		}, {
+			name: "unknown status",
+			json: `{"run":"r","event":"update","nodes":[{"id":"a","kind":"group","status":"waiting"}]}`,
+			err:  `unknown status: "waiting" of node "a"`,
+		}, {
+			name: "link without target",
+			json: `{"run":"r","event":"update","links":[{"source":"a/b","value":1}]}`,
+			err:  "missing field: source and target of link 0",
+		}, {
+			name: "running test linked to a group sent earlier",
+			json: `{"run":"r","event":"update","nodes":[{"id":"a/b","kind":"test","group":"a","status":"running"}],
+				"links":[{"source":"a/b","target":"a","value":1}]}`,
+		}, {
+			name: "start",
+			json: `{"run":"r","event":"start","nodes":[{"id":"a","kind":"group","status":"pending"}]}`,
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := report.DecodeProgress(strings.NewReader(tc.json))
+
+			if tc.err == "" {
+				assert.NoError(t, err)
+
+				return
+			}
+
+			assert.EqualError(t, err, tc.err)
+		})
+	}
+}
+
+func TestRunsCannotBeInProgress(t *testing.T) {
+	_, err := report.Decode(strings.NewReader(`{"version":2,"nodes":[{"id":"a","kind":"group","status":"running"}]}`))
+
+	assert.EqualError(t, err, `unknown status: "running" of node "a"`)
+}
@@ -0,0 +1,111 @@
+package report
+
+import "time"
+
+// Version of the run document described by this package.
+const Version = 2
+
+// Statuses a node can be reported with.
+const (
+	// StatusSkip marks nodes which did not run because of a failure.
+	StatusSkip = "skip"
+	StatusFail = "fail"
+	StatusPass = "pass"
+	// StatusSkipped marks tests which ran and skipped themselves.
+	StatusSkipped = "skipped"
+	// StatusNotRun marks planned nodes the run never reached.
+	StatusNotRun = "not-run"
+)
+
+// Kinds of nodes.
+const (
+	KindGroup = "group"
+	KindTest  = "test"
+)
+
+// Values a link is reported with, the UI uses them as relative lengths.
+const (
+	TestLink  = 1
+	GroupLink = 3
+)
+
+// Run describes the outcome of a single test run.
+type Run struct {
+	Version int    `json:"version"`
+	Commit  string `json:"commit,omitempty"`
+	Message string `json:"message,omitempty"`
+	Nodes   []Node `json:"nodes"`
+	Links   []Link `json:"links"`
+	// Shard is set when the run covers only a part of the graph.
+	Shard *Shard `json:"shard,omitempty"`
+	// ID identifies a run uploaded in several parts, e.g. by packages or
+	// shards, Parts tells how many of them to expect, 0 when not known.
+	ID    string `json:"id,omitempty"`
+	Parts int    `json:"parts,omitempty"`
+	// Part identifies the part within the run, uploading a part again
+	// replaces it, see PartID.
+	Part string `json:"part,omitempty"`
+	// Received and Complete are set by Merge, Complete once all the expected
+	// parts were merged, or when the server gave up waiting for them.
+	Received int  `json:"received,omitempty"`
+	Complete bool `json:"complete,omitempty"`
+}
+
+// Shard tells which part of the graph a run covers, the runs of all the
+// shards together cover the whole graph.
+type Shard struct {
+	// Index starts at 1 and goes up to Total.
+	Index int `json:"index"`
+	Total int `json:"total"`
+	// Groups lists the groups the shard is responsible for, it may run more
+	// of them, as some groups are needed by groups of several shards.
+	Groups []string `json:"groups"`
+}
+
+// Stable returns a copy of the run without the fields which change from one
+// run of the same graph to another: the commit, its message, the run id and
+// durations.
+func (r Run) Stable() Run {
+	stable := r
+	stable.Commit = ""
+	stable.Message = ""
+	stable.ID = ""
+	stable.Nodes = make([]Node, len(r.Nodes))
+
+	for i, n := range r.Nodes {
+		n.Duration = 0
+		stable.Nodes[i] = n
+	}
+
+	return stable
+}
+
+// Node is a group or a test taking part in a run.
+type Node struct {
+	// ID is unique within the run, it is the name for groups and TestID for tests.
+	ID    string `json:"id"`
+	Label string `json:"label"`
+	Kind  string `json:"kind"`
+	// Group is the name of the group a test belongs to, empty for groups.
+	Group    string        `json:"group,omitempty"`
+	Status   string        `json:"status"`
+	Duration time.Duration `json:"duration,omitempty"`
+	// SkippedBecause lists, for skipped nodes, the ids leading from the node
+	// that prevented it from running to the failed test the skip originates from.
+	SkippedBecause []string `json:"skippedBecause,omitempty"`
+	// CarriedOver marks nodes reported as they were in a previous run, which
+	// were not run again, see the runner's rerun-failed flag.
+	CarriedOver bool `json:"carriedOver,omitempty"`
+}
+
+// TestID builds the identity of a test, titles are only unique within a group.
+func TestID(group, title string) string {
+	return group + "/" + title
+}
+
+// Link connects a test to its group or a group to one of its dependencies.
+type Link struct {
+	Source string `json:"source"`
+	Target string `json:"target"`
+	Value  int    `json:"value"`
+}
@@ -0,0 +1,260 @@
+package report
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+var (
+	// ErrUnsupportedVersion returned when the document was produced by an unknown version.
+	ErrUnsupportedVersion = errors.New("unsupported version")
+	// ErrMissingField returned when a required field is absent or empty.
+	ErrMissingField = errors.New("missing field")
+	// ErrUnknownStatus returned when a node reports a status not listed in this package.
+	ErrUnknownStatus = errors.New("unknown status")
+	// ErrUnknownNode returned when a link references a node absent from the document.
+	ErrUnknownNode = errors.New("unknown node")
+	// ErrBadLinkValue returned when a link has a non positive value.
+	ErrBadLinkValue = errors.New("bad link value")
+	// ErrUnknownKind returned when a node is neither a group nor a test.
+	ErrUnknownKind = errors.New("unknown kind")
+	// ErrDuplicateNode returned when two nodes share the same id.
+	ErrDuplicateNode = errors.New("duplicate node")
+	// ErrBadShard returned when the shard index is not between 1 and the shard count.
+	ErrBadShard = errors.New("bad shard")
+	// ErrBadParts returned when a run is expected in a negative number of parts.
+	ErrBadParts = errors.New("bad parts")
+)
+
+const unknown = "unknown"
+
+// Decode reads a run document, validates it and returns its normalised form.
+func Decode(r io.Reader) (Run, error) {
+	var run Run
+
+	if err := json.NewDecoder(r).Decode(&run); err != nil {
+		return run, fmt.Errorf("decode run document: %w", err)
+	}
+
+	run.normalise()
+
+	if err := run.Validate(); err != nil {
+		return run, err
+	}
+
+	return run, nil
+}
+
+// normalise fills in the defaults for fields older runners may omit.
+func (r *Run) normalise() {
+	if r.Version == 0 {
+		// documents produced before versioning was introduced
+		r.Version = 1
+	}
+
+	if r.Version == 1 {
+		r.upgradeV1()
+	}
+
+	if r.Commit == "" {
+		r.Commit = unknown
+	}
+
+	if r.Message == "" {
+		r.Message = unknown
+	}
+
+	if r.Links == nil {
+		r.Links = make([]Link, 0)
+	}
+
+	for i := range r.Nodes {
+		if r.Nodes[i].Label == "" {
+			r.Nodes[i].Label = r.Nodes[i].ID
+		}
+	}
+}
+
+// upgradeV1 namespaces tests, which version 1 identified by title alone.
+// Version 1 runners emitted each group followed by its tests, and the links
+// of the tests in the same order, so the next test link tells whether the
+// next node is a test of the current group. They also left out the nodes
+// equal to one emitted before, those of tests sharing a title and a status,
+// which are restored.
+func (r *Run) upgradeV1() {
+	var tests []Link
+
+	for _, l := range r.Links {
+		if l.Value == TestLink {
+			tests = append(tests, l)
+		}
+	}
+
+	var (
+		nodes  = make([]Node, 0, len(r.Nodes))
+		titled = make(map[string]Node)
+		group  string
+		next   int
+	)
+
+	// restore adds the tests of the group linked before the node with the given id
+	restore := func(id string) {
+		for ; next < len(tests) && tests[next].Target == group && tests[next].Source != id; next++ {
+			n, ok := titled[tests[next].Source]
+			if !ok {
+				return
+			}
+
+			n.Group = group
+			n.ID = TestID(group, n.Label)
+			nodes = append(nodes, n)
+		}
+	}
+
+	for _, n := range r.Nodes {
+		restore(n.ID)
+
+		n.Label = n.ID
+		n.Kind = KindGroup
+
+		if next < len(tests) && tests[next].Source == n.ID && tests[next].Target == group {
+			n.Kind = KindTest
+			n.Group = group
+			n.ID = TestID(group, n.Label)
+			titled[n.Label] = n
+			next++
+		} else {
+			group = n.ID
+		}
+
+		nodes = append(nodes, n)
+	}
+
+	restore("")
+
+	r.Nodes = nodes
+
+	for i := range r.Links {
+		if l := &r.Links[i]; l.Value == TestLink {
+			l.Source = TestID(l.Target, l.Source)
+		}
+	}
+
+	r.Version = Version
+}
+
+// Validate checks the document is well formed.
+func (r Run) Validate() error {
+	if r.Version != Version {
+		return fmt.Errorf("%w: %d", ErrUnsupportedVersion, r.Version)
+	}
+
+	if len(r.Nodes) == 0 {
+		return fmt.Errorf("%w: nodes", ErrMissingField)
+	}
+
+	if r.Parts < 0 {
+		return fmt.Errorf("%w: %d", ErrBadParts, r.Parts)
+	}
+
+	if r.Parts > 0 && r.ID == "" {
+		return fmt.Errorf("%w: id of a run in %d parts", ErrMissingField, r.Parts)
+	}
+
+	if s := r.Shard; s != nil && (s.Index < 1 || s.Index > s.Total) {
+		return fmt.Errorf("%w: %d/%d", ErrBadShard, s.Index, s.Total)
+	}
+
+	ids := make(map[string]struct{}, len(r.Nodes))
+
+	for i, n := range r.Nodes {
+		if err := n.validate(i, knownStatus); err != nil {
+			return err
+		}
+
+		if _, ok := ids[n.ID]; ok {
+			return fmt.Errorf("%w: %q", ErrDuplicateNode, n.ID)
+		}
+
+		ids[n.ID] = struct{}{}
+	}
+
+	for _, n := range r.Nodes {
+		if _, ok := ids[n.Group]; n.Kind == KindTest && !ok {
+			return fmt.Errorf("%w: group %q of test %q", ErrUnknownNode, n.Group, n.ID)
+		}
+
+		for _, id := range n.SkippedBecause {
+			if _, ok := ids[id]; !ok {
+				return fmt.Errorf("%w: %q skipping %q", ErrUnknownNode, id, n.ID)
+			}
+		}
+	}
+
+	if r.Shard != nil {
+		for _, id := range r.Shard.Groups {
+			if _, ok := ids[id]; !ok {
+				return fmt.Errorf("%w: %q of shard %d", ErrUnknownNode, id, r.Shard.Index)
+			}
+		}
+	}
+
+	for i, l := range r.Links {
+		if err := l.validate(i); err != nil {
+			return err
+		}
+
+		for _, id := range []string{l.Source, l.Target} {
+			if _, ok := ids[id]; !ok {
+				return fmt.Errorf("%w: %q referenced by link %d", ErrUnknownNode, id, i)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (l Link) validate(i int) error {
+	if l.Source == "" || l.Target == "" {
+		return fmt.Errorf("%w: source and target of link %d", ErrMissingField, i)
+	}
+
+	if l.Value <= 0 {
+		return fmt.Errorf("%w: %d of link %d", ErrBadLinkValue, l.Value, i)
+	}
+
+	return nil
+}
+
+func (n Node) validate(i int, known func(string) bool) error {
+	if n.ID == "" {
+		return fmt.Errorf("%w: id of node %d", ErrMissingField, i)
+	}
+
+	if !known(n.Status) {
+		return fmt.Errorf("%w: %q of node %q", ErrUnknownStatus, n.Status, n.ID)
+	}
+
+	switch n.Kind {
+	case KindGroup:
+	case KindTest:
+		if n.Group == "" {
+			return fmt.Errorf("%w: group of test %q", ErrMissingField, n.ID)
+		}
+	default:
+		return fmt.Errorf("%w: %q of node %q", ErrUnknownKind, n.Kind, n.ID)
+	}
+
+	return nil
+}
+
+func knownStatus(s string) bool {
+	switch s {
+	case StatusSkip, StatusFail, StatusPass, StatusSkipped, StatusNotRun:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,186 @@
+package report_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/anatollupacescu/arbortest/report"
+)
+
+func TestDecode(t *testing.T) {
+	tt := []struct {
+		name string
+		json string
+		err  string
+	}{
+		{
+			name: "malformed json",
+			json: `{"nodes":[`,
+			err:  "decode run document: unexpected EOF",
+		}, {
+			name: "unsupported version",
+			json: `{"version":99,"nodes":[{"id":"a","kind":"group","status":"pass"}]}`,
+			err:  "unsupported version: 99",
+		}, {
+			name: "no nodes",
+			json: `{"version":2,"nodes":[]}`,
+			err:  "missing field: nodes",
+		}, {
+			name: "node without id",
+			json: `{"version":2,"nodes":[{"kind":"group","status":"pass"}]}`,
+			err:  "missing field: id of node 0",
+		}, {
+			name: "unknown status",
+			json: `{"version":2,"nodes":[{"id":"a","kind":"group","status":"green"}]}`,
+			err:  `unknown status: "green" of node "a"`,
+		}, {
+			name: "unknown kind",
+			json: `{"version":2,"nodes":[{"id":"a","kind":"suite","status":"pass"}]}`,
+			err:  `unknown kind: "suite" of node "a"`,
+		}, {
+			name: "test without group",
+			json: `{"version":2,"nodes":[{"id":"a","kind":"test","status":"pass"}]}`,
+			err:  `missing field: group of test "a"`,
+		}, {
+			name: "test of missing group",
+			json: `{"version":2,"nodes":[{"id":"b/a","kind":"test","group":"b","status":"pass"}]}`,
+			err:  `unknown node: group "b" of test "b/a"`,
+		}, {
+			name: "duplicate node",
+			json: `{"version":2,"nodes":[{"id":"a","kind":"group","status":"pass"},{"id":"a","kind":"group","status":"pass"}]}`,
+			err:  `duplicate node: "a"`,
+		}, {
+			name: "skipped because of missing node",
+			json: `{"version":2,"nodes":[{"id":"a","kind":"group","status":"skip","skippedBecause":["b"]}]}`,
+			err:  `unknown node: "b" skipping "a"`,
+		}, {
+			name: "link to missing node",
+			json: `{"version":2,"nodes":[{"id":"a","kind":"group","status":"pass"}],"links":[{"source":"a","target":"b","value":3}]}`,
+			err:  `unknown node: "b" referenced by link 0`,
+		}, {
+			name: "link without target",
+			json: `{"version":2,"nodes":[{"id":"a","kind":"group","status":"pass"}],"links":[{"source":"a","value":3}]}`,
+			err:  "missing field: source and target of link 0",
+		}, {
+			name: "link with zero value",
+			json: `{"version":2,"nodes":[{"id":"a","kind":"group","status":"pass"},{"id":"b","kind":"group","status":"fail"}],` +
+				`"links":[{"source":"a","target":"b"}]}`,
+			err: "bad link value: 0 of link 0",
+		}, {
+			name: "shard out of range",
+			json: `{"version":2,"nodes":[{"id":"a","kind":"group","status":"pass"}],"shard":{"index":3,"total":2,"groups":["a"]}}`,
+			err:  "bad shard: 3/2",
+		}, {
+			name: "shard responsible for missing group",
+			json: `{"version":2,"nodes":[{"id":"a","kind":"group","status":"pass"}],"shard":{"index":1,"total":2,"groups":["b"]}}`,
+			err:  `unknown node: "b" of shard 1`,
+		}, {
+			name: "parts without id",
+			json: `{"version":2,"nodes":[{"id":"a","kind":"group","status":"pass"}],"parts":2}`,
+			err:  "missing field: id of a run in 2 parts",
+		}, {
+			name: "negative parts",
+			json: `{"version":2,"nodes":[{"id":"a","kind":"group","status":"pass"}],"id":"x","parts":-1}`,
+			err:  "bad parts: -1",
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := report.Decode(strings.NewReader(tc.json))
+			assert.EqualError(t, err, tc.err)
+		})
+	}
+}
+
+func TestDecodeNormalises(t *testing.T) {
+	json := `{"version":2,"nodes":[{"id":"group","kind":"group","status":"pass"},` +
+		`{"id":"group/test","label":"test","kind":"test","group":"group","status":"pass"}],` +
+		`"links":[{"source":"group/test","target":"group","value":1}]}`
+
+	run, err := report.Decode(strings.NewReader(json))
+	assert.NoError(t, err)
+
+	expected := report.Run{
+		Version: report.Version,
+		Commit:  "unknown",
+		Message: "unknown",
+		Nodes: []report.Node{
+			{ID: "group", Label: "group", Kind: report.KindGroup, Status: report.StatusPass},
+			{ID: "group/test", Label: "test", Kind: report.KindTest, Group: "group", Status: report.StatusPass},
+		},
+		Links: []report.Link{
+			{Source: "group/test", Target: "group", Value: report.TestLink},
+		},
+	}
+	assert.Equal(t, expected, run)
+}
+
+func TestDecodeWithoutLinks(t *testing.T) {
+	run, err := report.Decode(strings.NewReader(`{"version":2,"commit":"abc","message":"fix","nodes":[{"id":"group","kind":"group","status":"skip"}]}`))
+	assert.NoError(t, err)
+	assert.Equal(t, "abc", run.Commit)
+	assert.Equal(t, "fix", run.Message)
+	assert.Empty(t, run.Links)
+	assert.NotNil(t, run.Links)
+}
+
+func TestDecodeUpgradesVersionOne(t *testing.T) {
+	json := `{"nodes":[
+		{"id":"group","status":"pass"},
+		{"id":"test","status":"pass"},
+		{"id":"group2","status":"skip"},
+		{"id":"test","status":"skip"}
+	],"links":[
+		{"source":"test","target":"group","value":1},
+		{"source":"test","target":"group2","value":1},
+		{"source":"group2","target":"group","value":3}
+	]}`
+
+	run, err := report.Decode(strings.NewReader(json))
+	assert.NoError(t, err)
+
+	assert.Equal(t, report.Version, run.Version)
+	assert.Equal(t, []report.Node{
+		{ID: "group", Label: "group", Kind: report.KindGroup, Status: report.StatusPass},
+		{ID: "group/test", Label: "test", Kind: report.KindTest, Group: "group", Status: report.StatusPass},
+		{ID: "group2", Label: "group2", Kind: report.KindGroup, Status: report.StatusSkip},
+		{ID: "group2/test", Label: "test", Kind: report.KindTest, Group: "group2", Status: report.StatusSkip},
+	}, run.Nodes)
+	assert.Equal(t, []report.Link{
+		{Source: "group/test", Target: "group", Value: report.TestLink},
+		{Source: "group2/test", Target: "group2", Value: report.TestLink},
+		{Source: "group2", Target: "group", Value: report.GroupLink},
+	}, run.Links)
+}
+
+func TestDecodeUpgradesVersionOneTestNamedAfterGroup(t *testing.T) {
+	json := `{"nodes":[
+		{"id":"b","status":"pass"},
+		{"id":"x","status":"pass"},
+		{"id":"y","status":"pass"},
+		{"id":"a","status":"fail"},
+		{"id":"b","status":"fail"}
+	],"links":[
+		{"source":"x","target":"b","value":1},
+		{"source":"y","target":"b","value":1},
+		{"source":"x","target":"a","value":1},
+		{"source":"b","target":"a","value":1}
+	]}`
+
+	run, err := report.Decode(strings.NewReader(json))
+	assert.NoError(t, err)
+
+	assert.Equal(t, []report.Node{
+		{ID: "b", Label: "b", Kind: report.KindGroup, Status: report.StatusPass},
+		{ID: "b/x", Label: "x", Kind: report.KindTest, Group: "b", Status: report.StatusPass},
+		{ID: "b/y", Label: "y", Kind: report.KindTest, Group: "b", Status: report.StatusPass},
+		{ID: "a", Label: "a", Kind: report.KindGroup, Status: report.StatusFail},
+		{ID: "a/x", Label: "x", Kind: report.KindTest, Group: "a", Status: report.StatusPass},
+		{ID: "a/b", Label: "b", Kind: report.KindTest, Group: "a", Status: report.StatusFail},
+	}, run.Nodes, "group b is not taken for the test of a named after it, the duplicate test x is restored")
+	assert.NoError(t, run.Validate())
+}
@@ -0,0 +1,101 @@
+package runner
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+//nolint:gochecknoglobals	//idiomatic way of working with flags in Go
+var dryRun = flag.Bool("arbor.dry-run", false, "print the execution plan instead of running the tests")
+
+// included tells whether the test would run with the current -run filter,
+// without running it.
+func (a *T) included(name string) bool {
+	t, ok := a.proxy.(*testing.T)
+	if !ok {
+		return true
+	}
+
+	var ran bool
+
+	t.Run(name, func(t *testing.T) {
+		ran = true
+
+		t.Skip("dry run")
+	})
+
+	return ran
+}
+
+// describe lists the groups in the order they run, with their dependencies and
+// tests, marking the ones the current filters exclude.
+func (g Graph) describe() string {
+	plan := g.order
+	if len(plan) == 0 {
+		plan = g.plan
+	}
+
+	if len(plan) == 0 {
+		// generated before plans were registered, only what ran is known
+		for _, grp := range g.groups {
+			plan = append(plan, PlannedGroup{Name: grp.name, After: g.deps[grp.name]})
+		}
+	}
+
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "arbor: dry run, %d groups in order", len(plan))
+
+	for i, p := range plan {
+		fmt.Fprintf(&b, "\n%d. %s", i+1, p.Name)
+
+		if !g.ran(p.Name) {
+			fmt.Fprint(&b, " (excluded)")
+		}
+
+		if len(p.After) > 0 {
+			fmt.Fprintf(&b, "\n   after: %s", strings.Join(mark(p.After, func(dep string) bool {
+				return external(dep) || g.ran(dep)
+			}), ", "))
+		}
+
+		ranTest := func(name string) bool {
+			for _, t := range g.groups.get(p.Name).tests {
+				if t.name == name {
+					return true
+				}
+			}
+
+			return false
+		}
+
+		tests := p.Tests
+		if len(tests) == 0 {
+			for _, t := range g.groups.get(p.Name).tests {
+				tests = append(tests, t.name)
+			}
+		}
+
+		if len(tests) > 0 {
+			fmt.Fprintf(&b, "\n   tests: %s", strings.Join(mark(tests, ranTest), ", "))
+		}
+	}
+
+	return b.String()
+}
+
+func mark(names []string, included func(string) bool) []string {
+	marked := make([]string, 0, len(names))
+
+	for _, n := range names {
+		if !included(n) {
+			n += " (excluded)"
+		}
+
+		marked = append(marked, n)
+	}
+
+	return marked
+}
@@ -0,0 +1,68 @@
+package runner
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDryRunDoesNotRunTests(t *testing.T) {
+	var counter int
+
+	g := New()
+	g.dryRun = true
+	g.Plan(
+		PlannedGroup{Name: "a", Tests: []string{"one"}},
+		PlannedGroup{Name: "b", After: []string{"a"}, Tests: []string{"two"}},
+		PlannedGroup{Name: "c", After: []string{"b"}, Tests: []string{"three"}},
+	)
+
+	at := NewT(&fakeT{})
+	g.Group("a")
+	g.Append(at, "one", func(at *T) {
+		counter++
+		at.Error("should not run")
+	})
+
+	// b is left out, as -run would do
+	g.Group("c")
+	g.After(at, "b")
+	g.Append(at, "three", func(at *T) {
+		counter++
+	})
+
+	expected := `arbor: dry run, 3 groups in order
+1. a
+   tests: one
+2. b (excluded)
+   after: a
+   tests: two (excluded)
+3. c
+   after: b (excluded)
+   tests: three`
+
+	assert.Equal(t, 0, counter)
+	assert.False(t, at.Failed())
+	assert.Equal(t, notRun, g.groups.get("c").status)
+	assert.Equal(t, []test{{name: "three", status: notRun}}, g.groups.get("c").tests)
+	assert.Equal(t, expected, g.describe())
+}
+
+func TestDryRunWithoutPlan(t *testing.T) {
+	g := New()
+	g.dryRun = true
+
+	at := NewT(&fakeT{})
+	g.Group("a")
+	g.Append(at, "one", func(at *T) {})
+	g.Group("b")
+	g.After(at, "a")
+
+	expected := `arbor: dry run, 2 groups in order
+1. a
+   tests: one
+2. b
+   after: a`
+
+	assert.Equal(t, expected, g.describe())
+}
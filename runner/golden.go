@@ -0,0 +1,55 @@
+package runner
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+//nolint:gochecknoglobals	//idiomatic way of working with flags in Go
+var update = flag.Bool("arbor.update", false, "overwrite golden files with the actual run documents")
+
+// AssertGolden compares a run document, as returned by StableJSON, with the
+// contents of a golden file. When the tests are run with -arbor.update the
+// golden file is written instead.
+func AssertGolden(t testing.TB, path, doc string) {
+	t.Helper()
+
+	actual, err := indent(doc)
+	if err != nil {
+		t.Fatalf("golden %s: format run document: %v", path, err)
+	}
+
+	if *update {
+		if err := ioutil.WriteFile(filepath.Clean(path), actual, 0600); err != nil {
+			t.Fatalf("golden %s: update: %v", path, err)
+		}
+
+		return
+	}
+
+	expected, err := ioutil.ReadFile(filepath.Clean(path))
+	if err != nil {
+		t.Fatalf("golden %s: %v, run with -arbor.update to create it", path, err)
+	}
+
+	if !bytes.Equal(expected, actual) {
+		t.Errorf("golden %s: run document differs, run with -arbor.update to accept it\n--- expected\n%s\n--- actual\n%s",
+			path, expected, actual)
+	}
+}
+
+func indent(doc string) ([]byte, error) {
+	var buf bytes.Buffer
+
+	if err := json.Indent(&buf, []byte(doc), "", "  "); err != nil {
+		return nil, err
+	}
+
+	buf.WriteByte('\n')
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,33 @@
+package runner_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/anatollupacescu/arbortest/runner"
+)
+
+func TestGolden(t *testing.T) {
+	var now time.Time
+
+	r := runner.New()
+	r.TimeProvider(func() time.Time {
+		now = now.Add(time.Millisecond)
+		return now
+	})
+
+	mock := &fakeT{}
+	rt := runner.NewT(mock)
+	r.Group("ingredient")
+	r.Append(rt, "create", func(*runner.T) {})
+	r.Append(rt, "update", func(at *runner.T) { at.Error("stop") })
+
+	for _, name := range []string{"recipe", "order", "menu"} {
+		rt = runner.NewT(&fakeT{})
+		r.Group(name)
+		r.After(rt, "ingredient")
+		r.Append(rt, "create", func(*runner.T) {})
+	}
+
+	runner.AssertGolden(t, "testdata/golden.json", r.StableJSON())
+}
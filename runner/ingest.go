@@ -0,0 +1,240 @@
+package runner
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/anatollupacescu/arbortest/report"
+)
+
+// maxEventSize is the longest line of go test -json output read, long logs included.
+const maxEventSize = 1024 * 1024
+
+// testEvent is a line of the output of go test -json, see go doc test2json.
+type testEvent struct {
+	Action  string
+	Test    string
+	Elapsed float64
+	Output  string
+}
+
+// Ingest exported, rebuilds the run of a TestArbor from the output of
+// go test -json, for when the tests are run by tools which only keep that.
+// The plan tells about the groups and tests which did not run and about the
+// dependencies explaining skips. Reporters are told about the run as if it
+// was going on, it ends once the output is read. Of runs repeated in
+// different orders only the last round is kept, the way Run does.
+func (g *Graph) Ingest(r io.Reader) error {
+	var (
+		round string
+		order []string
+		ended = make(map[string]string)
+		logs  = make(map[string][]string)
+		tests = make(map[string][]test)
+	)
+
+	lines := bufio.NewScanner(r)
+	lines.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), maxEventSize)
+
+	for lines.Scan() {
+		line := lines.Bytes()
+		if len(line) == 0 || line[0] != '{' {
+			// build errors and the like are not events
+			continue
+		}
+
+		var e testEvent
+		if err := json.Unmarshal(line, &e); err != nil {
+			return fmt.Errorf("decode test event: %w", err)
+		}
+
+		seed, path := subtest(e.Test)
+
+		if seed != "" && seed != round {
+			round = seed
+			order = nil
+			ended = make(map[string]string)
+			logs = make(map[string][]string)
+			tests = make(map[string][]test)
+		}
+
+		switch {
+		case len(path) == 0:
+		case e.Action == "output":
+			// the output of subtests of tests goes to the test
+			id := path[0]
+			if len(path) > 1 {
+				id = report.TestID(path[0], path[1])
+			}
+
+			if out := logLine(e.Output); out != "" {
+				logs[id] = append(logs[id], out)
+			}
+		case len(path) == 1 && e.Action == "run":
+			if _, ok := ended[path[0]]; !ok {
+				order = append(order, path[0])
+				ended[path[0]] = ""
+			}
+		case len(path) == 1 && ends(e.Action):
+			ended[path[0]] = e.Action
+		case len(path) == 2 && ends(e.Action):
+			t := ingested(e.Action, path[1], logs[report.TestID(path[0], path[1])])
+			t.duration = time.Duration(e.Elapsed * float64(time.Second))
+
+			tests[path[0]] = append(tests[path[0]], t)
+		}
+	}
+
+	if err := lines.Err(); err != nil {
+		return fmt.Errorf("read test events: %w", err)
+	}
+
+	for _, p := range g.plan {
+		g.deps[p.Name] = p.After
+	}
+
+	for _, name := range order {
+		g.replay(name, ended[name], tests[name], logs)
+	}
+
+	g.End()
+
+	return nil
+}
+
+// replay records a group the way Group, After and Append would have.
+func (g *Graph) replay(name, action string, tests []test, logs map[string][]string) {
+	g.runStart()
+	g.groupEnd()
+
+	g.currentGroupName = name
+	g.groups.add(group{name: name, status: pass})
+
+	grp := g.groups.get(name)
+
+	for _, r := range g.reporters {
+		r.GroupStart(name)
+	}
+
+	switch {
+	case skippedBy(name, "because dependency", logs[name]):
+		grp.status = skip
+
+		for _, dep := range g.deps[name] {
+			if status, cause, known := g.dependency(dep); known && status != pass {
+				grp.skippedBecause = cause
+
+				break
+			}
+		}
+
+		for _, r := range g.reporters {
+			r.GroupSkip(name, grp.skippedBecause)
+		}
+	case action == "fail":
+		grp.status = fail
+	}
+
+	grp.carried = len(tests) > 0
+
+	for _, t := range tests {
+		if t.status == skip {
+			t.skippedBecause = grp.cause()
+		}
+
+		if t.status == fail {
+			grp.status = fail
+		}
+
+		grp.carried = grp.carried && t.carried
+		grp.duration += t.duration
+		grp.tests = append(grp.tests, t)
+
+		if t.status != skip && !t.carried {
+			for _, r := range g.reporters {
+				r.TestStart(name, t.name)
+			}
+		}
+
+		g.testEnd(name, t, logs[report.TestID(name, t.name)])
+	}
+}
+
+// subtest returns the group and test names of a subtest of TestArbor, along
+// with the round of repeated runs in different orders it belongs to, if any.
+func subtest(name string) (string, []string) {
+	path := strings.Split(name, "/")
+	if path[0] != "TestArbor" {
+		return "", nil
+	}
+
+	path = path[1:]
+
+	if len(path) > 0 && strings.HasPrefix(path[0], "seed=") {
+		return path[0], path[1:]
+	}
+
+	return "", path
+}
+
+func ends(action string) bool {
+	return action == "pass" || action == "fail" || action == "skip"
+}
+
+// ingested tells apart the tests which skipped themselves from the ones the
+// runner skipped or carried over from a previous run, by the reason given.
+func ingested(action, name string, logs []string) test {
+	t := test{name: name}
+
+	switch action {
+	case "pass":
+		t.status = pass
+	case "fail":
+		t.status = fail
+	default:
+		t.status = skipped
+
+		const carried = "because it was reported as '"
+
+		for _, l := range logs {
+			if i := strings.Index(l, carried); i >= 0 && strings.Contains(l, "skipping '"+name+"'") {
+				t.status = parseStatus(strings.SplitN(l[i+len(carried):], "'", 2)[0])
+				t.carried = true
+
+				return t
+			}
+		}
+
+		if skippedBy(name, "because", logs) {
+			t.status = skip
+		}
+	}
+
+	return t
+}
+
+// skippedBy tells whether the runner skipped the group or test, giving the reason.
+func skippedBy(name, reason string, logs []string) bool {
+	for _, l := range logs {
+		if strings.Contains(l, "skipping '"+name+"' "+reason) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// logLine returns what the test logged, without the lines go test adds.
+func logLine(out string) string {
+	out = strings.TrimSpace(out)
+
+	if strings.HasPrefix(out, "=== ") || strings.HasPrefix(out, "--- ") {
+		return ""
+	}
+
+	return out
+}
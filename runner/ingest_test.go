@@ -0,0 +1,127 @@
+package runner
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/anatollupacescu/arbortest/report"
+)
+
+type logRecorder struct {
+	NopReporter
+
+	logs map[string][]string
+}
+
+func (l *logRecorder) TestEnd(r TestResult) {
+	l.logs[report.TestID(r.Group, r.Name)] = r.Logs
+}
+
+func TestIngest(t *testing.T) {
+	// go test -json -run '^TestArbor$' ./example
+	f, err := os.Open("testdata/gotest.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer f.Close()
+
+	rec := &logRecorder{logs: make(map[string][]string)}
+
+	g := New()
+	g.CommitInfoProvider(func() (string, string) { return "", "" })
+	g.Report(rec)
+	g.Plan(
+		PlannedGroup{Name: "ingredient", Tests: []string{"CreateSuccess", "UpdateWithNegativeValue"}},
+		PlannedGroup{Name: "recipe", After: []string{"ingredient"}, Tests: []string{"UpdateSuccess"}},
+		PlannedGroup{Name: "order", After: []string{"recipe", "ingredient"}, Tests: []string{"RejectsEmptyQuantity"}},
+		PlannedGroup{Name: "delivery", After: []string{"order"}, Tests: []string{"Ship"}},
+	)
+
+	assert.NoError(t, g.Ingest(f))
+
+	run := document(*g)
+
+	expected := []report.Node{
+		{ID: "ingredient", Label: "ingredient", Kind: report.KindGroup, Status: report.StatusPass},
+		{ID: "ingredient/CreateSuccess", Label: "CreateSuccess", Kind: report.KindTest, Group: "ingredient", Status: report.StatusPass},
+		{ID: "ingredient/UpdateWithNegativeValue", Label: "UpdateWithNegativeValue", Kind: report.KindTest, Group: "ingredient",
+			Status: report.StatusPass},
+		{ID: "recipe", Label: "recipe", Kind: report.KindGroup, Status: report.StatusFail},
+		{ID: "recipe/UpdateSuccess", Label: "UpdateSuccess", Kind: report.KindTest, Group: "recipe", Status: report.StatusFail},
+		{ID: "order", Label: "order", Kind: report.KindGroup, Status: report.StatusSkip,
+			SkippedBecause: []string{"recipe", "recipe/UpdateSuccess"}},
+		{ID: "order/RejectsEmptyQuantity", Label: "RejectsEmptyQuantity", Kind: report.KindTest, Group: "order", Status: report.StatusSkip,
+			SkippedBecause: []string{"order", "recipe", "recipe/UpdateSuccess"}},
+		{ID: "delivery", Label: "delivery", Kind: report.KindGroup, Status: report.StatusNotRun},
+		{ID: "delivery/Ship", Label: "Ship", Kind: report.KindTest, Group: "delivery", Status: report.StatusNotRun},
+	}
+
+	assert.Equal(t, expected, run.Nodes)
+	assert.NoError(t, run.Validate())
+	assert.Equal(t, []string{"p1_test.go:12: no"}, rec.logs["recipe/UpdateSuccess"])
+	assert.Contains(t, g.Summary(), "arbor: 1 passed, 1 failed, 1 skipped because of failed dependencies, 1 not run")
+}
+
+func TestIngestTellsSkipReasonsApart(t *testing.T) {
+	events := `{"Action":"run","Test":"TestArbor/seed=7/a"}
+{"Action":"output","Test":"TestArbor/seed=7/a/mine","Output":"    a_test.go:3: not today\n"}
+{"Action":"skip","Test":"TestArbor/seed=7/a/mine","Elapsed":0.5}
+{"Action":"output","Test":"TestArbor/seed=7/a/old","Output":"    proxy.go:250: skipping 'old' because it was reported as 'pass' in the previous run\n"}
+{"Action":"skip","Test":"TestArbor/seed=7/a/old","Elapsed":0}
+{"Action":"pass","Test":"TestArbor/seed=7/a","Elapsed":0.5}
+not json, e.g. a build error
+`
+
+	g := New()
+	g.CommitInfoProvider(func() (string, string) { return "", "" })
+
+	assert.NoError(t, g.Ingest(strings.NewReader(events)))
+
+	grp := g.groups.get("a")
+	assert.Equal(t, pass, grp.status)
+	assert.Equal(t, 500*time.Millisecond, grp.duration)
+	assert.Equal(t, []test{
+		{name: "mine", status: skipped, duration: 500 * time.Millisecond},
+		{name: "old", status: pass, carried: true},
+	}, grp.tests)
+}
+
+func TestIngestKeepsTheLastRound(t *testing.T) {
+	// go test -json -run '^TestArbor$' ./... -args -arbor.shuffle-runs=2
+	events := `{"Action":"run","Test":"TestArbor/seed=7/a"}
+{"Action":"output","Test":"TestArbor/seed=7/a/t","Output":"    a_test.go:3: first round\n"}
+{"Action":"fail","Test":"TestArbor/seed=7/a/t","Elapsed":0.5}
+{"Action":"fail","Test":"TestArbor/seed=7/a","Elapsed":0.5}
+{"Action":"run","Test":"TestArbor/seed=8/a"}
+{"Action":"output","Test":"TestArbor/seed=8/a/t","Output":"    a_test.go:3: second round\n"}
+{"Action":"fail","Test":"TestArbor/seed=8/a/t","Elapsed":0.25}
+{"Action":"fail","Test":"TestArbor/seed=8/a","Elapsed":0.25}
+{"Action":"output","Test":"TestArbor","Output":"--- FAIL: TestArbor (0.75s)\n"}
+{"Action":"fail","Test":"TestArbor","Elapsed":0.75}
+`
+
+	rec := &logRecorder{logs: make(map[string][]string)}
+
+	g := New()
+	g.CommitInfoProvider(func() (string, string) { return "", "" })
+	g.Report(rec)
+
+	assert.NoError(t, g.Ingest(strings.NewReader(events)))
+
+	assert.Len(t, g.groups, 1)
+	assert.Equal(t, []test{{name: "t", status: fail, duration: 250 * time.Millisecond}}, g.groups.get("a").tests)
+	assert.Equal(t, []string{"a_test.go:3: second round"}, rec.logs["a/t"])
+}
+
+func TestIngestRejectsMalformedEvents(t *testing.T) {
+	g := New()
+
+	err := g.Ingest(strings.NewReader(`{"Action":`))
+
+	assert.EqualError(t, err, "decode test event: unexpected end of JSON input")
+}
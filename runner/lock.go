@@ -0,0 +1,106 @@
+package runner
+
+import (
+	"sort"
+	"sync"
+)
+
+// Lock names an external resource, such as a database table or a port, which
+// tests holding the same lock never use at the same time. Locks only apply
+// within a single test process, arbortest run keeps apart the packages whose
+// tests hold the same lock.
+type Lock struct {
+	name   string
+	shared bool
+}
+
+// Exclusive exported, the test holding it runs alone among the tests holding the same lock.
+func Exclusive(name string) Lock {
+	return Lock{name: name}
+}
+
+// Shared exported, tests holding it shared run along each other but not along
+// a test holding it exclusively.
+func Shared(name string) Lock {
+	return Lock{name: name, shared: true}
+}
+
+// lockSet hands out one read-write mutex per lock name.
+type lockSet struct {
+	mu    sync.Mutex
+	locks map[string]*sync.RWMutex
+}
+
+func newLockSet() *lockSet {
+	return &lockSet{locks: make(map[string]*sync.RWMutex)}
+}
+
+func (s *lockSet) get(name string) *sync.RWMutex {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	l, ok := s.locks[name]
+	if !ok {
+		l = new(sync.RWMutex)
+		s.locks[name] = l
+	}
+
+	return l
+}
+
+// held are the locks of a single test.
+type held struct {
+	set   *lockSet
+	locks []Lock
+}
+
+func (s *lockSet) hold(locks []Lock) *held {
+	// a lock asked for both ways is held exclusively
+	byName := make(map[string]Lock, len(locks))
+	for _, l := range locks {
+		if prev, ok := byName[l.name]; !ok || prev.shared {
+			byName[l.name] = l
+		}
+	}
+
+	h := &held{set: s}
+	for _, l := range byName {
+		h.locks = append(h.locks, l)
+	}
+
+	// always taken in the same order, so that two tests can not wait for each other
+	sort.Slice(h.locks, func(i, j int) bool {
+		return h.locks[i].name < h.locks[j].name
+	})
+
+	return h
+}
+
+func (h *held) acquire() {
+	if h == nil {
+		return
+	}
+
+	for _, l := range h.locks {
+		if l.shared {
+			h.set.get(l.name).RLock()
+		} else {
+			h.set.get(l.name).Lock()
+		}
+	}
+}
+
+func (h *held) release() {
+	if h == nil {
+		return
+	}
+
+	for i := len(h.locks) - 1; i >= 0; i-- {
+		l := h.locks[i]
+		if l.shared {
+			h.set.get(l.name).RUnlock()
+		} else {
+			h.set.get(l.name).Unlock()
+		}
+	}
+}
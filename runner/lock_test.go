@@ -0,0 +1,118 @@
+package runner_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/anatollupacescu/arbortest/report"
+	"github.com/anatollupacescu/arbortest/runner"
+)
+
+func TestExclusiveLockAcrossParallelTests(t *testing.T) {
+	var running, most int32
+
+	t.Cleanup(func() {
+		assert.Equal(t, int32(1), most)
+	})
+
+	r := runner.New()
+	r.Group("group")
+
+	at := runner.NewT(t)
+
+	for i := 0; i < 4; i++ {
+		r.Append(at, fmt.Sprintf("test%d", i), func(at *runner.T) {
+			at.Parallel()
+
+			n := atomic.AddInt32(&running, 1)
+			defer atomic.AddInt32(&running, -1)
+
+			for {
+				m := atomic.LoadInt32(&most)
+				if n <= m || atomic.CompareAndSwapInt32(&most, m, n) {
+					break
+				}
+			}
+
+			time.Sleep(5 * time.Millisecond)
+		}, runner.Exclusive("db"))
+	}
+}
+
+func TestFailedParallelTestIsRecorded(t *testing.T) {
+	if out := os.Getenv("ARBOR_PARALLEL_OUT"); out != "" {
+		r := runner.New()
+		r.TimeProvider(frozen)
+		r.CommitInfoProvider(func() (string, string) { return "", "" })
+		r.Report(writeRun{out: out})
+		r.Group("group")
+
+		at := runner.NewT(t)
+
+		r.Append(at, "fails", func(at *runner.T) {
+			at.Parallel()
+			at.Error("parallel failure")
+		}, runner.Exclusive("db"))
+		r.Append(at, "passes", func(at *runner.T) {
+			at.Parallel()
+		}, runner.Exclusive("db"))
+
+		// the parallel tests are not done yet, the last one ends the run
+		r.End()
+
+		return
+	}
+
+	out := filepath.Join(t.TempDir(), "run.json")
+
+	cmd := exec.Command(os.Args[0], "-test.run=^TestFailedParallelTestIsRecorded$")
+	cmd.Env = append(os.Environ(), "ARBOR_PARALLEL_OUT="+out)
+
+	b, err := cmd.CombinedOutput()
+	assert.Error(t, err, "the failed test fails the process")
+	assert.Contains(t, string(b), "parallel failure")
+
+	b, err = ioutil.ReadFile(out)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	var run report.Run
+	if !assert.NoError(t, json.Unmarshal(b, &run)) {
+		return
+	}
+
+	expected := []report.Node{
+		{ID: "group", Label: "group", Kind: report.KindGroup, Status: report.StatusFail},
+		{ID: "group/fails", Label: "fails", Kind: report.KindTest, Group: "group", Status: report.StatusFail},
+		{ID: "group/passes", Label: "passes", Kind: report.KindTest, Group: "group", Status: report.StatusPass},
+	}
+	assert.Equal(t, expected, run.Nodes)
+}
+
+// writeRun saves the run document once the run ends.
+type writeRun struct {
+	runner.NopReporter
+
+	out string
+}
+
+func (w writeRun) RunEnd(run report.Run) {
+	b, err := json.Marshal(run)
+	if err == nil {
+		err = ioutil.WriteFile(w.out, b, 0600)
+	}
+
+	if err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,55 @@
+package runner
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSharedLocksAreHeldTogether(t *testing.T) {
+	s := newLockSet()
+
+	first := s.hold([]Lock{Shared("db")})
+	second := s.hold([]Lock{Shared("db")})
+
+	first.acquire()
+
+	acquired := make(chan struct{})
+
+	go func() {
+		second.acquire()
+		close(acquired)
+	}()
+
+	select {
+	case <-acquired:
+	case <-time.After(time.Second):
+		t.Fatal("shared lock held by one test at a time")
+	}
+
+	exclusive := s.hold([]Lock{Exclusive("db")})
+	taken := make(chan struct{})
+
+	go func() {
+		exclusive.acquire()
+		close(taken)
+	}()
+
+	first.release()
+	second.release()
+
+	select {
+	case <-taken:
+	case <-time.After(time.Second):
+		t.Fatal("exclusive lock not taken once the shared ones were released")
+	}
+
+	exclusive.release()
+}
+
+func TestHoldOrdersLocks(t *testing.T) {
+	h := newLockSet().hold([]Lock{Exclusive("port"), Shared("db"), Exclusive("db")})
+
+	assert.Equal(t, []Lock{Exclusive("db"), Exclusive("port")}, h.locks)
+}
@@ -5,42 +5,28 @@ import (
 	"log"
 	"os/exec"
 	"strings"
-)
-
-type node struct {
-	ID     string `json:"id"`
-	Status string `json:"status"`
-
-	groupName string
-}
 
-type link struct {
-	Source string `json:"source"`
-	Target string `json:"target"`
-	Value  int    `json:"value"`
-}
+	"github.com/anatollupacescu/arbortest/report"
+)
 
 type output struct {
-	Commit  string `json:"commit"`
-	Message string `json:"message"`
-	Nodes   []node `json:"nodes"`
-	Links   []link `json:"links"`
+	report.Run
 
-	nodes map[node]struct{}
-	links map[link]struct{}
+	nodes map[string]struct{}
+	links map[report.Link]struct{}
 }
 
-func (o *output) Node(n node) {
-	if _, isPresent := o.nodes[n]; isPresent {
+func (o *output) Node(n report.Node) {
+	if _, isPresent := o.nodes[n.ID]; isPresent {
 		return
 	}
 
 	o.Nodes = append(o.Nodes, n)
 
-	o.nodes[n] = struct{}{}
+	o.nodes[n.ID] = struct{}{}
 }
 
-func (o *output) Link(l link) {
+func (o *output) Link(l report.Link) {
 	if _, isPresent := o.links[l]; isPresent {
 		return
 	}
@@ -50,70 +36,151 @@ func (o *output) Link(l link) {
 	o.links[l] = struct{}{}
 }
 
-const (
-	betweenTests  = 1
-	betweenGroups = 3
-)
-
 func marshal(g Graph) string {
-	statuses := []string{"skip", "fail", "pass"}
+	return encode(document(g))
+}
 
-	out := output{
-		Commit:  "unknown",
-		Message: "unknown",
-		nodes:   make(map[node]struct{}),
-		links:   make(map[link]struct{}),
+func encode(run report.Run) string {
+	data, err := json.Marshal(run)
+	if err != nil {
+		log.Fatal(err)
 	}
 
+	return string(data)
+}
+
+func newOutput() output {
+	return output{
+		Run: report.Run{
+			Version: report.Version,
+			Commit:  "unknown",
+			Message: "unknown",
+		},
+		nodes: make(map[string]struct{}),
+		links: make(map[report.Link]struct{}),
+	}
+}
+
+// document is the run along with the commit it ran on.
+func document(g Graph) report.Run {
+	run := uncommitted(g)
+	run.Commit, run.Message = g.infoProvider()
+
+	return run
+}
+
+// uncommitted lists nodes and links in the order the groups were run, followed
+// by the planned ones which did not run, so the output is the same for every
+// run of the same graph. Groups left to other shards are not listed, those of
+// other packages only when known from the upstream results.
+func uncommitted(g Graph) report.Run {
+	out := newOutput()
+
 	for i := range g.groups {
 		grp := g.groups[i]
-		groupNode := node{
-			ID:     grp.name,
-			Status: statuses[grp.status],
+		groupNode := report.Node{
+			ID:       grp.name,
+			Label:    grp.name,
+			Kind:     report.KindGroup,
+			Status:   grp.status.String(),
+			Duration: grp.duration,
+
+			SkippedBecause: grp.skippedBecause,
+			CarriedOver:    grp.carried,
 		}
 
 		out.Node(groupNode)
 
 		for _, tst := range grp.tests {
-			testNode := node{
-				ID:        tst.name,
-				Status:    statuses[tst.status],
-				groupName: grp.name,
+			testNode := report.Node{
+				ID:       report.TestID(grp.name, tst.name),
+				Label:    tst.name,
+				Kind:     report.KindTest,
+				Group:    grp.name,
+				Status:   tst.status.String(),
+				Duration: tst.duration,
+
+				SkippedBecause: tst.skippedBecause,
+				CarriedOver:    tst.carried,
 			}
 			out.Node(testNode)
+			out.Link(testLink(testNode))
+		}
+
+		// planned tests the group did not get to, e.g. filtered out by -run
+		p, _ := g.planned(grp.name)
+		out.notRun(p.Name, p.Tests)
+	}
+
+	for _, p := range g.plan {
+		if !g.ran(p.Name) && !g.sharded(p.Name) {
+			out.Node(groupNode(p.Name, report.StatusNotRun, nil))
+			out.notRun(p.Name, p.Tests)
+		}
+	}
 
-			linkNode := link{
-				Source: tst.name,
-				Target: grp.name,
-				Value:  betweenTests,
+	// the nodes of other packages explaining skips
+	for _, n := range out.Nodes {
+		for _, id := range n.SkippedBecause {
+			if u, ok := g.upstream.node(id); ok && external(id) {
+				out.Node(u)
 			}
-			out.Link(linkNode)
 		}
 	}
 
-	for fromGroupName := range g.deps {
-		targetGroups := g.deps[fromGroupName]
+	for _, grp := range out.Nodes {
+		if grp.Kind != report.KindGroup || external(grp.ID) {
+			continue
+		}
+
+		targetGroups, ok := g.deps[grp.ID]
+		if !ok {
+			p, _ := g.planned(grp.ID)
+			targetGroups = p.After
+		}
 
 		for _, destinationGroupName := range targetGroups {
-			linkNode := link{
-				Source: fromGroupName,
+			if external(destinationGroupName) {
+				n, ok := g.upstream.node(destinationGroupName)
+				if !ok {
+					continue
+				}
+
+				out.Node(n)
+			} else {
+				// a group never declared, which the skips of its dependents name
+				out.Node(groupNode(destinationGroupName, report.StatusNotRun, nil))
+			}
+
+			linkNode := report.Link{
+				Source: grp.ID,
 				Target: destinationGroupName,
-				Value:  betweenGroups,
+				Value:  report.GroupLink,
 			}
 			out.Link(linkNode)
 		}
 	}
 
-	commit, message := g.infoProvider()
-	out.Message = message
-	out.Commit = commit
+	if g.shard != nil && g.shard.runs != nil {
+		out.Shard = g.shard.document(g.plan)
+	}
 
-	data, err := json.Marshal(out)
-	if err != nil {
-		log.Fatal(err)
+	if *runID != "" {
+		out.ID = *runID
+		out.Parts = *runParts
+		out.Part = *runPart
 	}
 
-	return string(data)
+	return out.Run
+}
+
+func (o *output) notRun(group string, tests []string) {
+	for _, name := range tests {
+		node := testNode(group, name, report.StatusNotRun)
+
+		o.Node(node)
+		o.Link(testLink(node))
+	}
 }
 
 func gitCommitAndMessage() (commit string, message string) {
@@ -127,7 +194,7 @@ func gitCommitAndMessage() (commit string, message string) {
 
 	out, err = exec.Command("git", "show-branch", "--no-name", "HEAD").Output()
 	if err != nil {
-		log.Fatal("online ", err)
+		return
 	}
 
 	message = string(out)
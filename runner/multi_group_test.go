@@ -4,6 +4,8 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+
+	"github.com/anatollupacescu/arbortest/report"
 )
 
 func TestTwoIndependentGroups(t *testing.T) {
@@ -94,3 +96,107 @@ func TestOneFailedDepSkipsDependant(t *testing.T) {
 	assert.Equal(t, pass, g.groups.get("testGroup2").status)
 	assert.Equal(t, skip, g.groups.get("testGroup3").status)
 }
+
+func TestSkipCausedBySkipPointsToOrigin(t *testing.T) {
+	g := New()
+
+	at1 := NewT(&fakeT{})
+	g.Group("testGroup1")
+	g.Append(at1, "test", func(at *T) {
+		at.Error()
+	})
+
+	at2 := NewT(&fakeT{})
+	g.Group("testGroup2")
+	g.After(at2, "testGroup1")
+	g.Append(at2, "test", func(at *T) {})
+
+	at3 := NewT(&fakeT{})
+	g.Group("testGroup3")
+	g.After(at3, "testGroup2")
+	g.Append(at3, "test", func(at *T) {})
+
+	assert.Equal(t, []string{"testGroup1", "testGroup1/test"}, g.groups.get("testGroup2").skippedBecause)
+	assert.Equal(t, []string{"testGroup2", "testGroup1", "testGroup1/test"}, g.groups.get("testGroup3").skippedBecause)
+
+	skipped := g.groups.get("testGroup3").tests[0]
+	assert.Equal(t, []string{"testGroup3", "testGroup2", "testGroup1", "testGroup1/test"}, skipped.skippedBecause)
+}
+
+func TestUnknownDepIsNotRun(t *testing.T) {
+	g := New()
+	g.TimeProvider(frozen)
+	g.CommitInfoProvider(func() (string, string) { return "", "" })
+
+	at := NewT(&fakeT{})
+	g.Group("testGroup")
+	g.After(at, "missing")
+	g.Append(at, "test", func(at *T) {
+		t.Error("should not have been called")
+	})
+
+	assert.Equal(t, skip, g.groups.get("testGroup").status)
+	assert.Equal(t, []string{"missing"}, g.groups.get("testGroup").skippedBecause)
+
+	run := document(*g)
+	assert.NoError(t, run.Validate())
+	assert.Contains(t, run.Nodes, groupNode("missing", report.StatusNotRun, nil))
+}
+
+func TestFailedDepSkipsInsteadOfFailing(t *testing.T) {
+	g := New()
+
+	g.Group("testGroup1")
+	g.Append(NewT(&fakeT{}), "test", func(at *T) {
+		at.Error()
+	})
+
+	mock := &fakeT{}
+	g.Group("testGroup2")
+	g.After(NewT(mock), "testGroup1")
+
+	assert.True(t, mock.skipped)
+	assert.False(t, mock.failed)
+}
+
+func TestStrictModeReportsSkipsAsFailures(t *testing.T) {
+	*strict = true
+
+	defer func() {
+		*strict = false
+	}()
+
+	g := New()
+
+	g.Group("testGroup1")
+	g.Append(NewT(&fakeT{}), "test", func(at *T) {
+		at.Error()
+	})
+
+	mock := &fakeT{}
+	g.Group("testGroup2")
+	g.After(NewT(mock), "testGroup1")
+
+	assert.False(t, mock.skipped)
+	assert.True(t, mock.failed)
+}
+
+func TestSummary(t *testing.T) {
+	g := New()
+
+	g.Group("testGroup1")
+	g.Append(NewT(&fakeT{}), "test", func(at *T) {
+		at.Error()
+	})
+
+	g.Group("testGroup2")
+	g.Append(NewT(&fakeT{}), "test", func(at *T) {})
+
+	g.Group("testGroup3")
+	g.After(NewT(&fakeT{}), "testGroup1")
+
+	expected := "arbor: 1 passed, 1 failed, 1 skipped because of failed dependencies\n" +
+		"failed: testGroup1\n" +
+		"skipped: testGroup3"
+	assert.Equal(t, expected, g.Summary())
+}
@@ -1,7 +1,10 @@
 package runner
 
 import (
+	"context"
+	"runtime"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -10,6 +13,7 @@ func TestSingleGroupSingleTest(t *testing.T) {
 	var counter int
 
 	g := New()
+	g.TimeProvider(frozen)
 
 	mock := &fakeT{}
 	at := NewT(mock)
@@ -36,6 +40,7 @@ func TestOneTestFail(t *testing.T) {
 	var counter int
 
 	g := New()
+	g.TimeProvider(frozen)
 
 	fakeT := &fakeT{}
 	at := NewT(fakeT)
@@ -62,6 +67,7 @@ func TestTwoTests(t *testing.T) {
 	var counter int
 
 	g := New()
+	g.TimeProvider(frozen)
 
 	fakeT := &fakeT{}
 	at := NewT(fakeT)
@@ -97,6 +103,7 @@ func TestFirstFailSkipsFollowingTests(t *testing.T) {
 	var counter int
 
 	g := New()
+	g.TimeProvider(frozen)
 
 	fakeT := &fakeT{}
 	at := NewT(fakeT)
@@ -122,15 +129,174 @@ func TestFirstFailSkipsFollowingTests(t *testing.T) {
 
 	real = g.groups.get("testGroup").tests[1]
 	expected = test{
-		name:   "test2",
-		status: skip,
+		name:           "test2",
+		status:         skip,
+		skippedBecause: []string{"testGroup", "testGroup/test1"},
 	}
 	assert.Equal(t, expected, real)
 }
 
+func TestContinuePolicyRunsTestsAfterFailure(t *testing.T) {
+	var counter int
+
+	g := New()
+	g.TimeProvider(frozen)
+
+	at := NewT(&fakeT{})
+	g.Group("testGroup")
+	g.OnFail(Continue)
+	g.Append(at, "test1", func(at *T) {
+		counter++
+		at.Error()
+	})
+	g.Append(at, "test2", func(at *T) {
+		counter++
+	})
+	g.Append(at, "test3", func(at *T) {
+		counter++
+		at.Error()
+	})
+
+	assert.Equal(t, 3, counter)
+	assert.Equal(t, fail, g.groups.get("testGroup").status)
+
+	expected := []test{
+		{name: "test1", status: fail},
+		{name: "test2", status: pass},
+		{name: "test3", status: fail},
+	}
+	assert.Equal(t, expected, g.groups.get("testGroup").tests)
+}
+
+func TestGoexitEndsOnlyTheTest(t *testing.T) {
+	var counter int
+
+	g := New()
+	g.TimeProvider(frozen)
+
+	at := NewT(&fakeT{})
+	g.Group("testGroup")
+	g.OnFail(Continue)
+	g.Append(at, "test1", func(at *T) {
+		at.Error()
+		runtime.Goexit()
+	})
+	g.Append(at, "test2", func(at *T) {
+		counter++
+	})
+
+	assert.Equal(t, 1, counter)
+	assert.Equal(t, fail, g.groups.get("testGroup").tests[0].status)
+	assert.Equal(t, pass, g.groups.get("testGroup").tests[1].status)
+}
+
+func TestFailNowStillRecordsStatus(t *testing.T) {
+	var counter int
+
+	g := New()
+	g.TimeProvider(frozen)
+
+	at := NewT(&fakeT{})
+	g.Group("testGroup")
+	g.OnFail(Continue)
+	g.Append(at, "test1", func(at *T) {
+		at.Fatal("stop")
+		counter++
+	})
+	g.Append(at, "test2", func(at *T) {
+		at.FailNow()
+		counter++
+	})
+	g.Append(at, "test3", func(at *T) {
+		at.SkipNow()
+		counter++
+	})
+
+	assert.Equal(t, 0, counter)
+
+	expected := []test{
+		{name: "test1", status: fail},
+		{name: "test2", status: fail},
+		{name: "test3", status: skipped},
+	}
+	assert.Equal(t, expected, g.groups.get("testGroup").tests)
+	assert.Equal(t, fail, g.groups.get("testGroup").status)
+}
+
+func TestSelfSkippedTestDoesNotFailGroup(t *testing.T) {
+	g := New()
+	g.TimeProvider(frozen)
+
+	at := NewT(&fakeT{})
+	g.Group("testGroup")
+	g.Append(at, "test1", func(at *T) {
+		at.Skip("not today")
+	})
+	g.Append(at, "test2", func(at *T) {})
+
+	expected := []test{
+		{name: "test1", status: skipped},
+		{name: "test2", status: pass},
+	}
+	assert.Equal(t, expected, g.groups.get("testGroup").tests)
+	assert.Equal(t, pass, g.groups.get("testGroup").status)
+}
+
+func TestDurationsAreRecorded(t *testing.T) {
+	var now time.Time
+
+	g := New()
+	g.TimeProvider(func() time.Time {
+		now = now.Add(time.Second)
+		return now
+	})
+
+	at := NewT(&fakeT{})
+	g.Group("testGroup")
+	g.Append(at, "test1", func(at *T) {})
+	g.Append(at, "test2", func(at *T) {})
+
+	grp := g.groups.get("testGroup")
+	assert.Equal(t, time.Second, grp.tests[0].duration)
+	assert.Equal(t, time.Second, grp.tests[1].duration)
+	assert.Equal(t, 2*time.Second, grp.duration)
+}
+
+func TestProxyWhichIsNotTB(t *testing.T) {
+	g := New()
+	g.TimeProvider(frozen)
+
+	var ctx context.Context
+
+	at := NewT(plainT{&fakeT{}})
+	g.Group("testGroup")
+	g.Append(at, "test", func(at *T) {
+		var tb testing.TB = at
+
+		ctx = tb.(interface{ Context() context.Context }).Context()
+	})
+
+	assert.Equal(t, pass, g.groups.get("testGroup").tests[0].status)
+	// fakeT runs the cleanups right away
+	assert.Error(t, ctx.Err())
+}
+
 // props
+func frozen() time.Time {
+	return time.Time{}
+}
+
+// plainT is a Testable and nothing more.
+type plainT struct {
+	Testable
+}
+
+// fakeT embeds testing.TB for the methods the tests do not rely on.
 type fakeT struct {
-	failed bool
+	testing.TB
+
+	failed  bool
+	skipped bool
 }
 
 func (f *fakeT) Failed() bool {
@@ -145,9 +311,28 @@ func (f *fakeT) Errorf(format string, args ...interface{}) {
 	f.failed = true
 }
 
+func (f *fakeT) Fail() {
+	f.failed = true
+}
+
+func (f *fakeT) Helper() {
+}
+
 func (f *fakeT) Log(args ...interface{}) {
 }
 
+func (f *fakeT) Logf(format string, args ...interface{}) {
+}
+
 func (f *fakeT) Run(name string, tf func(t *testing.T)) bool {
 	return true
 }
+
+func (f *fakeT) Skip(args ...interface{}) {
+	f.skipped = true
+}
+
+// Cleanup runs right away, fakes have no lifetime to hook into.
+func (f *fakeT) Cleanup(cf func()) {
+	cf()
+}
@@ -0,0 +1,149 @@
+package runner
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/anatollupacescu/arbortest/report"
+)
+
+// PlannedGroup describes a group as declared in the test files, before it runs.
+type PlannedGroup struct {
+	Name  string
+	After []string
+	Tests []string
+}
+
+// Plan exported, registers the whole graph up front so that groups and tests
+// which never run are still reported, with the not-run status.
+func (g *Graph) Plan(groups ...PlannedGroup) {
+	g.plan = append(g.plan, groups...)
+}
+
+// Define exported, registers the function appending the tests of a planned
+// group, Run calls it once the dependencies of the group are checked.
+func (g *Graph) Define(name string, f func(t *T)) {
+	g.bodies[name] = f
+}
+
+// Run exported, runs the defined groups as subtests of t, in the planned order,
+// in a random one valid for the dependencies when shuffling, or in the one
+// computed from the durations of previous runs when given their documents.
+func (g *Graph) Run(t Testable) {
+	rounds := g.shuffle.rounds()
+
+	if rounds == 1 {
+		g.runGroups(t, g.shuffle.next())
+
+		return
+	}
+
+	for i := 0; i < rounds; i++ {
+		seed := g.shuffle.next()
+
+		if i > 0 {
+			g.reset()
+		}
+
+		t.Run(fmt.Sprintf("seed=%d", seed), func(t *testing.T) {
+			g.runGroups(t, seed)
+		})
+
+		g.endRound(seed)
+	}
+}
+
+func (g *Graph) runGroups(t Testable, seed int64) {
+	g.order = g.plan
+
+	switch {
+	case g.shuffle.enabled:
+		g.order = shuffled(g.plan, seed)
+
+		t.Logf("arbor: groups shuffled, run with -arbor.shuffle=%d to get the same order", seed)
+	case g.history != nil:
+		g.order = g.history.schedule(g.plan, g.failFast)
+	}
+
+	if g.shard != nil {
+		g.shard.assign(g.plan, g.history)
+		g.order = g.shard.filter(g.order)
+
+		t.Logf("arbor: shard %d/%d runs %d of %d groups", g.shard.index, g.shard.total, len(g.order), len(g.plan))
+	}
+
+	for _, p := range g.order {
+		p := p
+
+		body, ok := g.bodies[p.Name]
+		if !ok {
+			continue
+		}
+
+		t.Run(p.Name, func(t *testing.T) {
+			at := NewT(t)
+			g.Group(p.Name)
+
+			if len(p.After) > 0 {
+				g.After(at, p.After...)
+			}
+
+			body(at)
+		})
+	}
+}
+
+func (g Graph) planned(name string) (PlannedGroup, bool) {
+	return planned(g.plan, name)
+}
+
+// sharded tells whether the group is left to another shard.
+func (g Graph) sharded(name string) bool {
+	return g.shard != nil && g.shard.runs != nil && !g.shard.runs[name]
+}
+
+func (g Graph) ran(name string) bool {
+	for _, grp := range g.groups {
+		if grp.name == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// pending returns the plan as a run document where nothing has run yet.
+func (g Graph) pending() report.Run {
+	out := newOutput()
+
+	for _, p := range g.plan {
+		if g.sharded(p.Name) {
+			continue
+		}
+
+		out.Node(groupNode(p.Name, report.StatusPending, nil))
+
+		for _, name := range p.Tests {
+			node := testNode(p.Name, name, report.StatusPending)
+
+			out.Node(node)
+			out.Link(testLink(node))
+		}
+	}
+
+	for _, p := range g.plan {
+		if g.sharded(p.Name) {
+			continue
+		}
+
+		for _, dep := range p.After {
+			if external(dep) {
+				continue
+			}
+
+			out.Link(report.Link{Source: p.Name, Target: dep, Value: report.GroupLink})
+		}
+	}
+
+	return out.Run
+}
@@ -0,0 +1,128 @@
+package runner
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/anatollupacescu/arbortest/report"
+)
+
+func TestPlannedNodesWhichDidNotRun(t *testing.T) {
+	g := New()
+	g.TimeProvider(frozen)
+	g.CommitInfoProvider(func() (string, string) { return "", "" })
+	g.Plan(
+		PlannedGroup{Name: "a", Tests: []string{"one", "two"}},
+		PlannedGroup{Name: "b", After: []string{"a"}, Tests: []string{"three"}},
+	)
+
+	at := NewT(&fakeT{})
+	g.Group("a")
+	g.Append(at, "one", func(at *T) {})
+
+	run := document(*g)
+
+	expectedNodes := []report.Node{
+		{ID: "a", Label: "a", Kind: report.KindGroup, Status: report.StatusPass},
+		{ID: "a/one", Label: "one", Kind: report.KindTest, Group: "a", Status: report.StatusPass},
+		{ID: "a/two", Label: "two", Kind: report.KindTest, Group: "a", Status: report.StatusNotRun},
+		{ID: "b", Label: "b", Kind: report.KindGroup, Status: report.StatusNotRun},
+		{ID: "b/three", Label: "three", Kind: report.KindTest, Group: "b", Status: report.StatusNotRun},
+	}
+
+	expectedLinks := []report.Link{
+		{Source: "a/one", Target: "a", Value: report.TestLink},
+		{Source: "a/two", Target: "a", Value: report.TestLink},
+		{Source: "b/three", Target: "b", Value: report.TestLink},
+		{Source: "b", Target: "a", Value: report.GroupLink},
+	}
+
+	assert.Equal(t, expectedNodes, run.Nodes)
+	assert.Equal(t, expectedLinks, run.Links)
+	assert.NoError(t, run.Validate())
+
+	assert.Equal(t, "arbor: 1 passed, 0 failed, 0 skipped because of failed dependencies, 1 not run\nnot run: b", g.Summary())
+}
+
+func TestTestsFilteredOutByRunAreNotRun(t *testing.T) {
+	if out := os.Getenv("ARBOR_FILTERED_OUT"); out != "" {
+		g := New()
+		g.TimeProvider(frozen)
+		g.CommitInfoProvider(func() (string, string) { return "", "" })
+		g.Plan(PlannedGroup{Name: "a", Tests: []string{"one", "two"}})
+
+		at := NewT(t)
+		g.Group("a")
+		g.Append(at, "one", func(at *T) {})
+		g.Append(at, "two", func(at *T) {})
+
+		b, err := json.Marshal(document(*g))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := ioutil.WriteFile(out, b, 0600); err != nil {
+			t.Fatal(err)
+		}
+
+		return
+	}
+
+	out := filepath.Join(t.TempDir(), "run.json")
+
+	cmd := exec.Command(os.Args[0], "-test.run=^TestTestsFilteredOutByRunAreNotRun$/^one$")
+	cmd.Env = append(os.Environ(), "ARBOR_FILTERED_OUT="+out)
+
+	b, err := cmd.CombinedOutput()
+	if !assert.NoError(t, err, string(b)) {
+		return
+	}
+
+	b, err = ioutil.ReadFile(out)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	var run report.Run
+	if !assert.NoError(t, json.Unmarshal(b, &run)) {
+		return
+	}
+
+	expectedNodes := []report.Node{
+		{ID: "a", Label: "a", Kind: report.KindGroup, Status: report.StatusPass},
+		{ID: "a/one", Label: "one", Kind: report.KindTest, Group: "a", Status: report.StatusPass},
+		{ID: "a/two", Label: "two", Kind: report.KindTest, Group: "a", Status: report.StatusNotRun},
+	}
+
+	assert.Equal(t, expectedNodes, run.Nodes)
+}
+
+func TestPendingPlan(t *testing.T) {
+	g := New()
+	g.Plan(
+		PlannedGroup{Name: "a", Tests: []string{"one"}},
+		PlannedGroup{Name: "b", After: []string{"a"}},
+	)
+
+	plan := g.pending()
+
+	expectedNodes := []report.Node{
+		{ID: "a", Label: "a", Kind: report.KindGroup, Status: report.StatusPending},
+		{ID: "a/one", Label: "one", Kind: report.KindTest, Group: "a", Status: report.StatusPending},
+		{ID: "b", Label: "b", Kind: report.KindGroup, Status: report.StatusPending},
+	}
+
+	expectedLinks := []report.Link{
+		{Source: "a/one", Target: "a", Value: report.TestLink},
+		{Source: "b", Target: "a", Value: report.GroupLink},
+	}
+
+	assert.Equal(t, expectedNodes, plan.Nodes)
+	assert.Equal(t, expectedLinks, plan.Links)
+}
@@ -1,48 +1,337 @@
 package runner
 
-import "testing"
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"testing"
+)
 
 // Testable exported.
 type Testable interface {
-	Failed() bool
+	Cleanup(func())
 	Error(...interface{})
 	Errorf(string, ...interface{})
+	Fail()
+	FailNow()
+	Failed() bool
+	Fatal(...interface{})
+	Fatalf(string, ...interface{})
+	Helper()
 	Log(...interface{})
+	Logf(string, ...interface{})
+	Name() string
+	Skip(...interface{})
+	SkipNow()
+	Skipf(string, ...interface{})
+	Skipped() bool
+	TempDir() string
 	Run(string, func(t *testing.T)) bool
 }
 
-// T exported.
+// T exported, it can be passed to helpers accepting a testing.TB.
 type T struct {
+	// TB is the proxy when it is a testing.TB and a stand-in otherwise,
+	// embedding it makes T satisfy the interface, whose unexported method can
+	// not be implemented otherwise. Helper is promoted from it rather than
+	// forwarded, so that it marks the frame of its caller.
+	testing.TB
+
 	proxy Testable
+	// logs keeps what the test printed, for the reporters.
+	logs []string
+	// held are the locks of the test, released while waiting in Parallel.
+	held *held
+	// resumed is called once the test holds its locks again after Parallel.
+	resumed func()
 }
 
+//nolint:gochecknoglobals	//compile time check
+var _ testing.TB = (*T)(nil)
+
 // NewT exported.
 func NewT(t Testable) *T {
-	return &T{proxy: t}
+	tb, ok := t.(testing.TB)
+	if !ok {
+		tb = newStandIn(t)
+	}
+
+	return &T{TB: tb, proxy: t}
 }
 
-// Failed exported.
-func (a *T) Failed() bool {
-	return a.proxy.Failed()
+func (a *T) record(args ...interface{}) {
+	a.logs = append(a.logs, strings.TrimSuffix(fmt.Sprintln(args...), "\n"))
+}
+
+func (a *T) recordf(format string, args ...interface{}) {
+	a.logs = append(a.logs, fmt.Sprintf(format, args...))
+}
+
+// Cleanup exported.
+func (a *T) Cleanup(f func()) {
+	a.proxy.Helper()
+	a.proxy.Cleanup(f)
 }
 
 // Error exported.
 func (a *T) Error(args ...interface{}) {
+	a.proxy.Helper()
+	a.record(args...)
 	a.proxy.Error(args...)
 }
 
 // Errorf exported.
 func (a *T) Errorf(format string, args ...interface{}) {
+	a.proxy.Helper()
+	a.recordf(format, args...)
 	a.proxy.Errorf(format, args...)
 }
 
+// Fail exported.
+func (a *T) Fail() {
+	a.proxy.Helper()
+	a.proxy.Fail()
+}
+
+// FailNow exported.
+func (a *T) FailNow() {
+	a.proxy.Helper()
+	a.proxy.FailNow()
+}
+
+// Failed exported.
+func (a *T) Failed() bool {
+	return a.proxy.Failed()
+}
+
+// Fatal exported.
+func (a *T) Fatal(args ...interface{}) {
+	a.proxy.Helper()
+	a.record(args...)
+	a.proxy.Fatal(args...)
+}
+
+// Fatalf exported.
+func (a *T) Fatalf(format string, args ...interface{}) {
+	a.proxy.Helper()
+	a.recordf(format, args...)
+	a.proxy.Fatalf(format, args...)
+}
+
 // Log exported.
 func (a *T) Log(args ...interface{}) {
+	a.proxy.Helper()
+	a.record(args...)
 	a.proxy.Log(args...)
 }
 
-// Run exported.
+// Logf exported.
+func (a *T) Logf(format string, args ...interface{}) {
+	a.proxy.Helper()
+	a.recordf(format, args...)
+	a.proxy.Logf(format, args...)
+}
+
+// Name exported.
+func (a *T) Name() string {
+	return a.proxy.Name()
+}
+
+// Skip exported.
+func (a *T) Skip(args ...interface{}) {
+	a.proxy.Helper()
+	a.record(args...)
+	a.proxy.Skip(args...)
+}
+
+// SkipNow exported.
+func (a *T) SkipNow() {
+	a.proxy.Helper()
+	a.proxy.SkipNow()
+}
+
+// Skipf exported.
+func (a *T) Skipf(format string, args ...interface{}) {
+	a.proxy.Helper()
+	a.recordf(format, args...)
+	a.proxy.Skipf(format, args...)
+}
+
+// Skipped exported.
+func (a *T) Skipped() bool {
+	return a.proxy.Skipped()
+}
+
+// TempDir exported.
+func (a *T) TempDir() string {
+	return a.proxy.TempDir()
+}
+
+// Parallel exported, when the proxy can run in parallel the test releases
+// its locks while waiting for its siblings and takes them again before going on.
+func (a *T) Parallel() {
+	p, ok := a.proxy.(interface{ Parallel() })
+	if !ok {
+		return
+	}
+
+	a.held.release()
+	p.Parallel()
+	a.held.acquire()
+
+	if a.resumed != nil {
+		a.resumed()
+	}
+}
+
+// Run exported, it runs f as a subtest of the proxy.
 func (a *T) Run(name string, f func(t *testing.T)) bool {
-	f(nil)
-	return true
+	a.proxy.Helper()
+
+	return a.proxy.Run(name, f)
+}
+
+// run executes f as a named child of a, holding the given locks, with its own
+// status. Once the locks are held it calls start, and end with the status and
+// what the child logged once f is done. A child which calls Parallel is done
+// after the caller of run returns, end is called from its goroutine then.
+// A *testing.T spawns a subtest, any other Testable runs f on its own
+// goroutine, the way the testing package does, so that FailNow, SkipNow and
+// runtime.Goexit only end f.
+func (a *T) run(name string, f func(t *T), h *held, start func(), end func(status, []string)) {
+	if t, ok := a.proxy.(*testing.T); ok {
+		var ran bool
+
+		t.Run(name, func(t *testing.T) {
+			ran = true
+
+			child := NewT(t)
+			child.held = h
+			child.resumed = start
+
+			h.acquire()
+			defer h.release()
+
+			start()
+
+			defer func() {
+				switch {
+				case t.Failed():
+					end(fail, child.logs)
+				case t.Skipped():
+					end(skipped, child.logs)
+				default:
+					end(pass, child.logs)
+				}
+			}()
+
+			f(child)
+		})
+
+		if !ran {
+			// filtered out by -run
+			end(notRun, nil)
+		}
+
+		return
+	}
+
+	child := &scoped{Testable: a.proxy}
+	// the methods scoped does not keep track of act on the parent
+	scopedT := &T{TB: a.TB, proxy: child, held: h}
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		h.acquire()
+		defer h.release()
+
+		start()
+
+		defer func() {
+			switch {
+			case child.failed:
+				end(fail, scopedT.logs)
+			case child.skipped:
+				end(skipped, scopedT.logs)
+			default:
+				end(pass, scopedT.logs)
+			}
+		}()
+
+		f(scopedT)
+	}()
+
+	<-done
+}
+
+// skip records a named child of a which does not run.
+func (a *T) skip(name, reason string) {
+	if t, ok := a.proxy.(*testing.T); ok {
+		t.Run(name, func(t *testing.T) {
+			t.Skip(reason)
+		})
+	}
+}
+
+// scoped forwards to its parent while keeping track of its own status, it
+// ends the goroutine started by run where testing.T would end the test.
+type scoped struct {
+	Testable
+
+	failed, skipped bool
+}
+
+func (s *scoped) Failed() bool {
+	return s.failed
+}
+
+func (s *scoped) Skipped() bool {
+	return s.skipped
+}
+
+func (s *scoped) Fail() {
+	s.failed = true
+	s.Testable.Fail()
+}
+
+func (s *scoped) Error(args ...interface{}) {
+	s.failed = true
+	s.Testable.Error(args...)
+}
+
+func (s *scoped) Errorf(format string, args ...interface{}) {
+	s.failed = true
+	s.Testable.Errorf(format, args...)
+}
+
+func (s *scoped) FailNow() {
+	s.Fail()
+	runtime.Goexit()
+}
+
+func (s *scoped) Fatal(args ...interface{}) {
+	s.Error(args...)
+	runtime.Goexit()
+}
+
+func (s *scoped) Fatalf(format string, args ...interface{}) {
+	s.Errorf(format, args...)
+	runtime.Goexit()
+}
+
+func (s *scoped) SkipNow() {
+	s.skipped = true
+	runtime.Goexit()
+}
+
+func (s *scoped) Skip(args ...interface{}) {
+	s.Log(args...)
+	s.SkipNow()
+}
+
+func (s *scoped) Skipf(format string, args ...interface{}) {
+	s.Log(fmt.Sprintf(format, args...))
+	s.SkipNow()
 }
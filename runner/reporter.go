@@ -0,0 +1,108 @@
+package runner
+
+import (
+	"time"
+
+	"github.com/anatollupacescu/arbortest/report"
+)
+
+// Reporter receives the progress of a run as it happens, statuses are the ones
+// defined in the report package.
+type Reporter interface {
+	// RunStart receives the plan, where every node is pending.
+	RunStart(plan report.Run)
+	// RunEnd receives the same document Graph.JSON encodes.
+	RunEnd(run report.Run)
+	GroupStart(name string)
+	// GroupSkip is called when a failed dependency prevents the group from running,
+	// its tests are still reported as skipped and the group still ends.
+	GroupSkip(name string, because []string)
+	GroupEnd(result GroupResult)
+	TestStart(group, name string)
+	TestEnd(result TestResult)
+}
+
+// GroupResult describes a group once all its tests have been appended.
+type GroupResult struct {
+	Name           string
+	Deps           []string
+	Status         string
+	Duration       time.Duration
+	SkippedBecause []string
+}
+
+// TestResult describes a test once it has run or has been skipped.
+type TestResult struct {
+	Group          string
+	Name           string
+	Status         string
+	Duration       time.Duration
+	Logs           []string
+	SkippedBecause []string
+}
+
+// NopReporter ignores every callback, embed it to implement only some of them.
+type NopReporter struct{}
+
+// RunStart exported.
+func (NopReporter) RunStart(report.Run) {}
+
+// RunEnd exported.
+func (NopReporter) RunEnd(report.Run) {}
+
+// GroupStart exported.
+func (NopReporter) GroupStart(string) {}
+
+// GroupSkip exported.
+func (NopReporter) GroupSkip(string, []string) {}
+
+// GroupEnd exported.
+func (NopReporter) GroupEnd(GroupResult) {}
+
+// TestStart exported.
+func (NopReporter) TestStart(string, string) {}
+
+// TestEnd exported.
+func (NopReporter) TestEnd(TestResult) {}
+
+//nolint:gochecknoglobals	//reporters are registered from the test files next to the generated one
+var registered []Reporter
+
+// Register attaches reporters to every Graph created by New from then on, call it
+// from an init function or TestMain in the package of the generated TestArbor.
+func Register(r ...Reporter) {
+	registered = append(registered, r...)
+}
+
+func (g *Graph) runStart() {
+	if g.started {
+		return
+	}
+
+	g.started = true
+
+	plan := g.pending()
+
+	for _, r := range g.reporters {
+		r.RunStart(plan)
+	}
+}
+
+func (g *Graph) groupEnd() {
+	if g.currentGroupName == "" {
+		return
+	}
+
+	grp := g.groups.get(g.currentGroupName)
+	result := GroupResult{
+		Name:           grp.name,
+		Deps:           g.deps[grp.name],
+		Status:         grp.status.String(),
+		Duration:       grp.duration,
+		SkippedBecause: grp.skippedBecause,
+	}
+
+	for _, r := range g.reporters {
+		r.GroupEnd(result)
+	}
+}
@@ -0,0 +1,122 @@
+package runner
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/anatollupacescu/arbortest/report"
+	"github.com/stretchr/testify/assert"
+)
+
+type recorder struct {
+	events []string
+	tests  []TestResult
+	run    report.Run
+}
+
+func (r *recorder) RunStart(report.Run) {
+	r.events = append(r.events, "run start")
+}
+
+func (r *recorder) RunEnd(run report.Run) {
+	r.events = append(r.events, "run end")
+	r.run = run
+}
+
+func (r *recorder) GroupStart(name string) {
+	r.events = append(r.events, "group start "+name)
+}
+
+func (r *recorder) GroupSkip(name string, because []string) {
+	r.events = append(r.events, fmt.Sprintf("group skip %s %v", name, because))
+}
+
+func (r *recorder) GroupEnd(result GroupResult) {
+	r.events = append(r.events, fmt.Sprintf("group end %s %s", result.Name, result.Status))
+}
+
+func (r *recorder) TestStart(group, name string) {
+	r.events = append(r.events, fmt.Sprintf("test start %s %s", group, name))
+}
+
+func (r *recorder) TestEnd(result TestResult) {
+	r.events = append(r.events, fmt.Sprintf("test end %s %s %s", result.Group, result.Name, result.Status))
+	r.tests = append(r.tests, result)
+}
+
+func TestReportersFollowTheRun(t *testing.T) {
+	first, second := &recorder{}, &recorder{}
+
+	g := New()
+	g.TimeProvider(frozen)
+	g.CommitInfoProvider(func() (string, string) { return "", "" })
+	g.Report(first, second)
+
+	at := NewT(&fakeT{})
+	g.Group("a")
+	g.Append(at, "pass", func(at *T) {
+		at.Logf("created %d", 1)
+	})
+	g.Append(at, "fail", func(at *T) {
+		at.Fatal("broken")
+	})
+
+	bt := NewT(&fakeT{})
+	g.Group("b")
+	g.After(bt, "a")
+	g.Append(bt, "never", func(at *T) {})
+
+	g.End()
+	g.End()
+
+	expected := []string{
+		"run start",
+		"group start a",
+		"test start a pass",
+		"test end a pass pass",
+		"test start a fail",
+		"test end a fail fail",
+		"group end a fail",
+		"group start b",
+		"group skip b [a a/fail]",
+		"test end b never skip",
+		"group end b skip",
+		"run end",
+	}
+
+	assert.Equal(t, expected, first.events)
+	assert.Equal(t, expected, second.events)
+
+	assert.Equal(t, []string{"created 1"}, first.tests[0].Logs)
+	assert.Equal(t, []string{"broken"}, first.tests[1].Logs)
+	assert.Equal(t, []string{"b", "a", "a/fail"}, first.tests[2].SkippedBecause)
+
+	assert.Equal(t, document(*g), first.run)
+}
+
+func TestRegisteredReportersAreAttachedByNew(t *testing.T) {
+	r := &recorder{}
+
+	Register(r)
+	defer func() {
+		registered = nil
+	}()
+
+	g := New()
+	g.TimeProvider(frozen)
+	g.CommitInfoProvider(func() (string, string) { return "", "" })
+	g.Group("a")
+	g.End()
+
+	assert.Equal(t, []string{"run start", "group start a", "group end a pass", "run end"}, r.events)
+}
+
+func TestNopReporterIsAReporter(t *testing.T) {
+	var r Reporter = struct{ NopReporter }{}
+
+	g := New()
+	g.Report(r)
+	g.CommitInfoProvider(func() (string, string) { return "", "" })
+	g.Group("a")
+	g.End()
+}
@@ -0,0 +1,149 @@
+package runner
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/anatollupacescu/arbortest/report"
+)
+
+//nolint:gochecknoglobals	//idiomatic way of working with flags in Go
+var rerunFailed = flag.String("arbor.rerun-failed", "",
+	"previous run document, only the tests which did not pass in it, and the groups they depend on, are run")
+
+// rerun selects the part of a previous run which has to run again, the rest
+// is carried over.
+type rerun struct {
+	previous map[string]report.Node
+	// whole lists the groups all tests of which run, the ones the failures depend on.
+	whole map[string]bool
+	// tests lists the tests which did not pass.
+	tests map[string]bool
+	// targets lists the groups of those tests.
+	targets map[string]bool
+}
+
+func loadRerun(path string) (*rerun, error) {
+	previous, err := readRun(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return newRerun(previous), nil
+}
+
+func readRun(path string) (report.Run, error) {
+	f, err := os.Open(filepath.Clean(path))
+	if err != nil {
+		return report.Run{}, err
+	}
+
+	defer func() {
+		_ = f.Close()
+	}()
+
+	run, err := report.Decode(f)
+	if err != nil {
+		return run, fmt.Errorf("read %s: %w", path, err)
+	}
+
+	return run, nil
+}
+
+func newRerun(previous report.Run) *rerun {
+	r := &rerun{
+		previous: make(map[string]report.Node, len(previous.Nodes)),
+		whole:    make(map[string]bool),
+		tests:    make(map[string]bool),
+		targets:  make(map[string]bool),
+	}
+
+	for _, n := range previous.Nodes {
+		r.previous[n.ID] = n
+
+		// tests which skipped themselves may not skip this time
+		if n.Status == report.StatusPass {
+			continue
+		}
+
+		switch n.Kind {
+		case report.KindTest:
+			r.tests[n.ID] = true
+			r.targets[n.Group] = true
+		case report.KindGroup:
+			r.targets[n.ID] = true
+		}
+	}
+
+	deps := make(map[string][]string)
+
+	for _, l := range previous.Links {
+		if l.Value == report.GroupLink {
+			deps[l.Source] = append(deps[l.Source], l.Target)
+		}
+	}
+
+	queue := make([]string, 0, len(r.targets))
+	for name := range r.targets {
+		queue = append(queue, deps[name]...)
+	}
+
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+
+		if r.whole[name] {
+			continue
+		}
+
+		r.whole[name] = true
+		queue = append(queue, deps[name]...)
+	}
+
+	return r
+}
+
+// runs tells whether the test has to run again, tests and groups absent from
+// the previous run always do.
+func (r *rerun) runs(group, name string) bool {
+	id := report.TestID(group, name)
+
+	if _, ok := r.previous[id]; !ok || r.whole[group] {
+		return true
+	}
+
+	return r.tests[id]
+}
+
+// carries tells whether none of the tests of the group run again.
+func (r *rerun) carries(group string) bool {
+	_, ok := r.previous[group]
+
+	return ok && !r.whole[group] && !r.targets[group]
+}
+
+// carry records the test as it was in the previous run.
+func (r *rerun) carry(group, name string) test {
+	prev := r.previous[report.TestID(group, name)]
+
+	return test{
+		name:           name,
+		status:         parseStatus(prev.Status),
+		duration:       prev.Duration,
+		skippedBecause: prev.SkippedBecause,
+		carried:        true,
+	}
+}
+
+// parseStatus is the reverse of status.String, Decode lets only known statuses through.
+func parseStatus(s string) status {
+	for st := skip; st < notRun; st++ {
+		if st.String() == s {
+			return st
+		}
+	}
+
+	return notRun
+}
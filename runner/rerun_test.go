@@ -0,0 +1,126 @@
+package runner
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/anatollupacescu/arbortest/report"
+)
+
+func previousRun() report.Run {
+	node := func(group, name, status string) report.Node {
+		n := testNode(group, name, status)
+		n.Duration = time.Second
+
+		return n
+	}
+
+	return report.Run{
+		Version: report.Version,
+		Nodes: []report.Node{
+			groupNode("a", report.StatusPass, nil),
+			node("a", "one", report.StatusPass),
+			groupNode("b", report.StatusFail, nil),
+			node("b", "two", report.StatusPass),
+			node("b", "three", report.StatusFail),
+			groupNode("c", report.StatusSkip, []string{"b", "b/three"}),
+			node("c", "four", report.StatusSkip),
+			groupNode("d", report.StatusPass, nil),
+			node("d", "five", report.StatusPass),
+			groupNode("e", report.StatusFail, nil),
+			node("e", "six", report.StatusPass),
+			node("e", "seven", report.StatusFail),
+			groupNode("f", report.StatusNotRun, nil),
+			node("f", "eight", report.StatusNotRun),
+			groupNode("g", report.StatusPass, nil),
+			node("g", "nine", report.StatusSkipped),
+		},
+		Links: []report.Link{
+			{Source: "b", Target: "a", Value: report.GroupLink},
+			{Source: "c", Target: "b", Value: report.GroupLink},
+		},
+	}
+}
+
+func TestRerunSelection(t *testing.T) {
+	r := newRerun(previousRun())
+
+	assert.True(t, r.runs("a", "one"), "ancestor of a failure")
+	assert.True(t, r.runs("b", "two"), "ancestor of a skip")
+	assert.False(t, r.runs("e", "six"), "passed next to a failure")
+	assert.True(t, r.runs("e", "seven"), "failed")
+	assert.True(t, r.runs("b", "three"), "failed")
+	assert.True(t, r.runs("c", "four"), "skipped")
+	assert.False(t, r.runs("d", "five"), "independent")
+	assert.True(t, r.runs("d", "six"), "new test")
+	assert.True(t, r.runs("f", "eight"), "not run")
+	assert.True(t, r.runs("g", "nine"), "skipped itself")
+
+	assert.False(t, r.carries("a"))
+	assert.False(t, r.carries("b"))
+	assert.False(t, r.carries("e"))
+	assert.True(t, r.carries("d"))
+	assert.False(t, r.carries("f"))
+	assert.False(t, r.carries("g"))
+	assert.False(t, r.carries("f"), "new group")
+}
+
+func TestRerunCarriesOverPasses(t *testing.T) {
+	var ran []string
+
+	g := New()
+	g.TimeProvider(frozen)
+	g.rerun = newRerun(previousRun())
+
+	record := func(name string) func(*T) {
+		return func(*T) {
+			ran = append(ran, name)
+		}
+	}
+
+	at := NewT(&fakeT{})
+
+	for _, grp := range []struct {
+		name  string
+		deps  []string
+		tests []string
+	}{
+		{name: "a", tests: []string{"one"}},
+		{name: "b", deps: []string{"a"}, tests: []string{"two", "three"}},
+		{name: "c", deps: []string{"b"}, tests: []string{"four"}},
+		{name: "d", tests: []string{"five"}},
+		{name: "e", tests: []string{"six", "seven"}},
+		{name: "f", tests: []string{"eight"}},
+		{name: "g", tests: []string{"nine"}},
+	} {
+		g.Group(grp.name)
+
+		if len(grp.deps) > 0 {
+			g.After(at, grp.deps...)
+		}
+
+		for _, name := range grp.tests {
+			g.Append(at, name, record(name))
+		}
+	}
+
+	assert.Equal(t, []string{"one", "two", "three", "four", "seven", "eight", "nine"}, ran)
+
+	run := document(*g)
+
+	carried := make(map[string]bool)
+
+	for _, n := range run.Nodes {
+		assert.Equal(t, report.StatusPass, n.Status, n.ID)
+
+		if n.CarriedOver {
+			carried[n.ID] = true
+		}
+	}
+
+	assert.Equal(t, map[string]bool{"d": true, "d/five": true, "e/six": true}, carried)
+	assert.Equal(t, time.Second, g.groups.get("d").duration)
+	assert.Contains(t, g.Summary(), "\ncarried over from the previous run: d")
+}
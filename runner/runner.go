@@ -1,22 +1,81 @@
 package runner
 
+import (
+	"flag"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/anatollupacescu/arbortest/report"
+)
+
+//nolint:gochecknoglobals	//idiomatic way of working with flags in Go
+var strict = flag.Bool("arbor.strict", false, "report groups skipped because of a failed dependency as failures")
+
 type status uint8
 
+// String returns the report status the runner status is exported as.
+func (s status) String() string {
+	return [...]string{report.StatusSkip, report.StatusFail, report.StatusPass, report.StatusSkipped, report.StatusNotRun}[s]
+}
+
 const (
+	// skip is the status of tests not run because of a failure.
 	skip status = iota
 	fail
 	pass
+	// skipped is the status of tests which called Skip themselves.
+	skipped
+	// notRun is the status of planned tests the run never reached.
+	notRun
+)
+
+// Policy decides whether the tests of a group keep running after one of them fails.
+type Policy uint8
+
+// Policies a group can be declared with, Stop is the default.
+const (
+	Stop Policy = iota
+	Continue
 )
 
 type group struct {
-	name   string
-	status status
-	tests  []test
+	name     string
+	status   status
+	policy   Policy
+	tests    []test
+	duration time.Duration
+	// skippedBecause lists the node ids leading to the failed test which caused the skip.
+	skippedBecause []string
+	// carried is set for groups none of the tests of which ran again, see rerun.
+	carried bool
 }
 
 type test struct {
-	name   string
-	status status
+	name           string
+	status         status
+	duration       time.Duration
+	skippedBecause []string
+	// carried is set for tests reported as they were in a previous run.
+	carried bool
+}
+
+// cause returns the chain of node ids explaining why this group did not pass,
+// ending with the failed test it originates from.
+func (g group) cause() []string {
+	if g.status == skip {
+		return append([]string{g.name}, g.skippedBecause...)
+	}
+
+	for _, t := range g.tests {
+		if t.status == fail {
+			return []string{g.name, report.TestID(g.name, t.name)}
+		}
+	}
+
+	return []string{g.name}
 }
 
 type groups []group
@@ -42,69 +101,424 @@ type Graph struct {
 	deps             map[string][]string
 	currentGroupName string
 	infoProvider     infoProvider
+	timeProvider     timeProvider
+	reporters        []Reporter
+	plan             []PlannedGroup
+	started, ended   bool
+	// dryRun records the groups and tests without running them.
+	dryRun bool
+	// rerun, when set, only runs what did not pass in a previous run.
+	rerun *rerun
+	// bodies append the tests of the planned groups, see Define.
+	bodies  map[string]func(t *T)
+	shuffle *shuffler
+	rounds  []round
+	locks   *lockSet
+	// history orders the groups using previous runs, failFast puts the ones
+	// which failed most often first.
+	history  *history
+	failFast bool
+	// order is the one the groups were last run in by Run.
+	order []PlannedGroup
+	// shard is set when the run covers a part of the graph only.
+	shard *shard
+	// upstream holds the results of the groups of other packages this one depends on.
+	upstream *upstream
+	// mu guards the graph while tests which called Parallel end on their own
+	// goroutines, running counts the tests which did not end yet.
+	mu          *sync.Mutex
+	running     int
+	endWhenDone bool
 }
 
 type infoProvider func() (string, string)
 
+type timeProvider func() time.Time
+
 // New exported.
 func New() *Graph {
-	return &Graph{
+	g := &Graph{
 		groups:       make(groups, 0),
 		deps:         make(map[string][]string),
 		infoProvider: gitCommitAndMessage,
+		timeProvider: time.Now,
+		dryRun:       *dryRun,
+		bodies:       make(map[string]func(t *T)),
+		locks:        newLockSet(),
+		mu:           new(sync.Mutex),
+	}
+
+	s, err := newShuffler(*shuffleMode, *shuffleRuns)
+	if err != nil {
+		log.Fatalf("shuffle groups: %s", err)
+	}
+
+	g.shuffle = s
+
+	if *historyFiles != "" {
+		h, err := loadHistory(*historyFiles)
+		if err != nil {
+			log.Fatalf("schedule groups: %s", err)
+		}
+
+		g.history = h
+		g.failFast = *failFast
+	}
+
+	if *shardFlag != "" {
+		s, err := parseShard(*shardFlag)
+		if err != nil {
+			log.Fatalf("shard groups: %s", err)
+		}
+
+		g.shard = s
+	}
+
+	if *upstreamFile != "" {
+		u, err := loadUpstream(*upstreamFile)
+		if err != nil {
+			log.Fatalf("read upstream results: %s", err)
+		}
+
+		g.upstream = u
+	}
+
+	if g.dryRun {
+		return g
+	}
+
+	if *rerunFailed != "" {
+		r, err := loadRerun(*rerunFailed)
+		if err != nil {
+			log.Fatalf("rerun failed tests: %s", err)
+		}
+
+		g.rerun = r
+	}
+
+	g.reporters = append(g.reporters, registered...)
+
+	if *live != "" {
+		g.reporters = append(g.reporters, newStreamer(*live))
 	}
+
+	if *traceFile != "" {
+		g.reporters = append(g.reporters, newTracer(*traceFile, func() time.Time {
+			return g.timeProvider()
+		}))
+	}
+
+	return g
+}
+
+// Report attaches reporters to the graph, on top of the registered ones.
+func (g *Graph) Report(r ...Reporter) {
+	g.reporters = append(g.reporters, r...)
 }
 
 // After exported.
 func (g Graph) After(t *T, dependencies ...string) {
 	g.deps[g.currentGroupName] = dependencies
 
+	if g.dryRun || g.groups.get(g.currentGroupName).carried {
+		return
+	}
+
 	for _, dependsOn := range dependencies {
-		dep := g.groups.get(dependsOn)
-		if dep.status != pass {
-			t.Errorf("skipping '%s' because dependency '%s' has failed", g.currentGroupName, dependsOn)
+		status, cause, known := g.dependency(dependsOn)
+		if known && status != pass {
+			origin := cause[len(cause)-1]
 
-			g.groups.get(g.currentGroupName).status = skip
+			reason := fmt.Sprintf("skipping '%s' because dependency '%s' has failed at '%s'", g.currentGroupName, dependsOn, origin)
+
+			switch {
+			case len(cause) == 1:
+				reason = fmt.Sprintf("skipping '%s' because dependency '%s' did not run", g.currentGroupName, dependsOn)
+			case status == skip:
+				reason = fmt.Sprintf("skipping '%s' because dependency '%s' was skipped after '%s' failed", g.currentGroupName, dependsOn, origin)
+			}
+
+			if *strict {
+				t.Error(reason)
+			} else {
+				// the tests of this group still have to be recorded, so skip once the group is done
+				t.Cleanup(func() {
+					t.Skip(reason)
+				})
+			}
+
+			grp := g.groups.get(g.currentGroupName)
+			grp.status = skip
+			grp.skippedBecause = cause
+
+			for _, r := range g.reporters {
+				r.GroupSkip(grp.name, cause)
+			}
 
 			return
 		}
 	}
 }
 
+// dependency returns the status of a group the current one depends on and the
+// chain of node ids explaining it, groups of other packages are only known
+// when given the upstream results.
+func (g Graph) dependency(name string) (status, []string, bool) {
+	if external(name) {
+		n, ok := g.upstream.node(name)
+		if !ok {
+			return notRun, nil, false
+		}
+
+		return parseStatus(n.Status), g.upstream.cause(name), true
+	}
+
+	if !g.ran(name) {
+		// not declared, declared after the group or filtered out by -run
+		return notRun, []string{name}, true
+	}
+
+	dep := g.groups.get(name)
+
+	return dep.status, append([]string{name}, dep.cause()[1:]...), true
+}
+
 // Group exported.
 func (g *Graph) Group(name string) {
+	g.runStart()
+	g.groupEnd()
+
 	g.currentGroupName = name
 	g.groups.add(group{
 		name:   name,
 		status: pass,
 	})
+
+	if g.dryRun {
+		g.groups.get(name).status = notRun
+	}
+
+	if g.rerun != nil {
+		g.groups.get(name).carried = g.rerun.carries(name)
+	}
+
+	for _, r := range g.reporters {
+		r.GroupStart(name)
+	}
 }
 
-// Append exported.
-func (g *Graph) Append(t *T, name string, f func(t *T)) {
+// Append exported, the test holds the given locks while it runs.
+func (g *Graph) Append(t *T, name string, f func(t *T), locks ...Lock) {
 	grp := g.groups.get(g.currentGroupName)
-	if t.Failed() || grp.status == skip {
-		grp.tests = append(grp.tests, test{
-			name:   name,
-			status: skip,
-		})
+
+	if g.dryRun {
+		if t.included(name) {
+			grp.tests = append(grp.tests, test{name: name, status: notRun})
+		}
 
 		return
 	}
 
-	f(t)
+	if g.rerun != nil && !g.rerun.runs(grp.name, name) {
+		node := g.rerun.carry(grp.name, name)
 
-	node := test{
-		name:   name,
-		status: pass,
+		t.skip(name, fmt.Sprintf("skipping '%s' because it was reported as '%s' in the previous run", name, node.status))
+
+		grp.duration += node.duration
+		grp.tests = append(grp.tests, node)
+
+		g.testEnd(grp.name, node, nil)
+
+		return
+	}
+
+	stopped := grp.policy == Stop && (t.Failed() || grp.status == fail)
+	if stopped || grp.status == skip {
+		cause := grp.cause()
+
+		t.skip(name, fmt.Sprintf("skipping '%s' because '%s' has failed", name, cause[len(cause)-1]))
+
+		node := test{
+			name:           name,
+			status:         skip,
+			skippedBecause: cause,
+		}
+
+		grp.tests = append(grp.tests, node)
+
+		g.testEnd(grp.name, node, nil)
+
+		return
+	}
+
+	for _, r := range g.reporters {
+		r.TestStart(grp.name, name)
+	}
+
+	// the node is updated in place, by then later tests or groups may have been appended
+	grpName, i := grp.name, len(grp.tests)
+	grp.tests = append(grp.tests, test{name: name, status: pass})
+
+	var start time.Time
+
+	g.mu.Lock()
+	g.running++
+	g.mu.Unlock()
+
+	t.run(name, f, g.locks.hold(locks), func() {
+		start = g.timeProvider()
+	}, func(s status, logs []string) {
+		g.mu.Lock()
+
+		grp := g.groups.get(grpName)
+		node := &grp.tests[i]
+		node.status = s
+
+		if s != notRun {
+			node.duration = g.timeProvider().Sub(start)
+		}
+
+		grp.duration += node.duration
+
+		if node.status == fail {
+			grp.status = fail
+		}
+
+		g.testEnd(grpName, *node, logs)
+
+		g.running--
+		ended := g.running == 0 && g.endWhenDone
+		g.mu.Unlock()
+
+		if ended {
+			g.End()
+		}
+	})
+}
+
+func (g *Graph) testEnd(grp string, node test, logs []string) {
+	result := TestResult{
+		Group:          grp,
+		Name:           node.name,
+		Status:         node.status.String(),
+		Duration:       node.duration,
+		Logs:           logs,
+		SkippedBecause: node.skippedBecause,
+	}
+
+	for _, r := range g.reporters {
+		r.TestEnd(result)
+	}
+}
+
+// End exported, tells the reporters the run is over, further calls do nothing.
+// On a dry run it prints the plan instead.
+func (g *Graph) End() {
+	if g.ended {
+		return
+	}
+
+	g.mu.Lock()
+	if g.running > 0 {
+		// tests which called Parallel are not done yet, the last one ends the run
+		g.endWhenDone = true
+		g.mu.Unlock()
+
+		return
+	}
+	g.mu.Unlock()
+
+	g.runStart()
+	g.groupEnd()
+
+	g.ended = true
+
+	if g.dryRun {
+		fmt.Println(g.describe())
+
+		return
+	}
+
+	run := document(*g)
+
+	for _, r := range g.reporters {
+		r.RunEnd(run)
+	}
+}
+
+// OnFail exported, sets the policy of the current group.
+func (g *Graph) OnFail(p Policy) {
+	g.groups.get(g.currentGroupName).policy = p
+}
+
+// Summary exported, tells apart the groups which failed from the ones skipped
+// because a group they depend on did not pass.
+func (g Graph) Summary() string {
+	if g.dryRun {
+		return "arbor: dry run, no test was run"
+	}
+
+	var passed, failed, skipped, notRun, carried []string
+
+	for _, p := range g.plan {
+		if !g.ran(p.Name) && !g.sharded(p.Name) {
+			notRun = append(notRun, p.Name)
+		}
+	}
+
+	for _, grp := range g.groups {
+		if grp.carried {
+			carried = append(carried, grp.name)
+		}
+
+		switch grp.status {
+		case pass:
+			passed = append(passed, grp.name)
+		case fail:
+			failed = append(failed, grp.name)
+		case skip:
+			skipped = append(skipped, grp.name)
+		}
 	}
 
-	if t.Failed() {
-		grp.status = fail
-		node.status = fail
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "arbor: %d passed, %d failed, %d skipped because of failed dependencies", len(passed), len(failed), len(skipped))
+
+	if len(notRun) > 0 {
+		fmt.Fprintf(&b, ", %d not run", len(notRun))
+	}
+
+	if len(failed) > 0 {
+		fmt.Fprintf(&b, "\nfailed: %s", strings.Join(failed, ", "))
+	}
+
+	if len(skipped) > 0 {
+		fmt.Fprintf(&b, "\nskipped: %s", strings.Join(skipped, ", "))
+	}
+
+	if len(notRun) > 0 {
+		fmt.Fprintf(&b, "\nnot run: %s", strings.Join(notRun, ", "))
+	}
+
+	if len(carried) > 0 {
+		fmt.Fprintf(&b, "\ncarried over from the previous run: %s", strings.Join(carried, ", "))
 	}
 
-	grp.tests = append(grp.tests, node)
+	if g.shard != nil && g.shard.runs != nil {
+		d := g.shard.document(g.plan)
+		fmt.Fprintf(&b, "\nshard %d/%d responsible for: %s", d.Index, d.Total, strings.Join(d.Groups, ", "))
+	}
+
+	if len(g.rounds) > 1 {
+		fmt.Fprintf(&b, "\n%d runs in different orders", len(g.rounds))
+
+		for _, d := range g.orderDependent() {
+			fmt.Fprintf(&b, "\norder dependent: %s", d)
+		}
+	}
+
+	return b.String()
 }
 
 // JSON exported.
@@ -112,7 +526,19 @@ func (g Graph) JSON() string {
 	return marshal(g)
 }
 
+// StableJSON exported, same as JSON but without the fields which change from
+// one run to another, such as the commit and durations, it does not look
+// the commit up.
+func (g Graph) StableJSON() string {
+	return encode(uncommitted(g).Stable())
+}
+
 // CommitInfoProvider allows swapping for a different title and message provider.
 func (g *Graph) CommitInfoProvider(f infoProvider) {
 	g.infoProvider = f
 }
+
+// TimeProvider allows swapping the clock used to measure test durations.
+func (g *Graph) TimeProvider(f timeProvider) {
+	g.timeProvider = f
+}
@@ -1,8 +1,13 @@
 package runner_test
 
 import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
@@ -12,18 +17,20 @@ import (
 func TestSingleAppend(t *testing.T) {
 	rt := runner.NewT(t)
 	r := runner.New()
+	r.TimeProvider(frozen)
 	r.Group("group")
 	r.Append(rt, "test", func(*runner.T) {})
 
 	json := `{
+		"version":2,
 		"commit":"test",
 		"message":"test",
 		"nodes":[
-		{"id": "group", "status":"pass"},
-		{"id": "test",  "status":"pass"}
-	], 
+		{"id":"group",	"label":"group",	"kind":"group",	"status":"pass"},
+		{"id":"group/test",	"label":"test",	"kind":"test",	"group":"group",	"status":"pass"}
+	],
 	"links":[
-		{"source": "test","target": "group","value": 1}
+		{"source":"group/test","target":"group","value":1}
 	]}`
 
 	json = strings.ReplaceAll(json, "\t", "")
@@ -39,21 +46,23 @@ func TestSingleAppend(t *testing.T) {
 func TestTwoAppend(t *testing.T) {
 	rt := runner.NewT(t)
 	r := runner.New()
+	r.TimeProvider(frozen)
 	r.Group("group")
 	r.Append(rt, "test", func(*runner.T) {})
 	r.Append(rt, "test2", func(*runner.T) {})
 
 	json := `{
+		"version":2,
 		"commit":"test",
 		"message":"test",
 		"nodes":[
-		{"id": "group", "status":"pass"},
-		{"id": "test",  "status":"pass"},
-		{"id": "test2",  "status":"pass"}
-	], 
+		{"id":"group",	"label":"group",	"kind":"group",	"status":"pass"},
+		{"id":"group/test",	"label":"test",	"kind":"test",	"group":"group",	"status":"pass"},
+		{"id":"group/test2",	"label":"test2",	"kind":"test",	"group":"group",	"status":"pass"}
+	],
 	"links":[
-		{"source": "test","target": "group","value": 1},
-		{"source": "test2","target": "group","value": 1}
+		{"source":"group/test","target":"group","value":1},
+		{"source":"group/test2","target":"group","value":1}
 	]}`
 
 	json = strings.ReplaceAll(json, "\t", "")
@@ -69,6 +78,7 @@ func TestTwoAppend(t *testing.T) {
 func TestTwoGrops(t *testing.T) {
 	rt := runner.NewT(t)
 	r := runner.New()
+	r.TimeProvider(frozen)
 	r.Group("group")
 	r.Append(rt, "test1", func(*runner.T) {})
 	r.Append(rt, "test2", func(*runner.T) {})
@@ -78,21 +88,22 @@ func TestTwoGrops(t *testing.T) {
 	r.Append(rt, "test4", func(*runner.T) {})
 
 	json := `{
+		"version":2,
 		"commit":"test",
 		"message":"test",
 		"nodes":[
-		{"id": "group", "status":"pass"},
-		{"id": "test1", "status":"pass"},
-		{"id": "test2", "status":"pass"},
-		{"id": "group2","status":"pass"},
-		{"id": "test3", "status":"pass"},
-		{"id": "test4", "status":"pass"}
-	], 
+		{"id":"group",	"label":"group",	"kind":"group",	"status":"pass"},
+		{"id":"group/test1",	"label":"test1",	"kind":"test",	"group":"group",	"status":"pass"},
+		{"id":"group/test2",	"label":"test2",	"kind":"test",	"group":"group",	"status":"pass"},
+		{"id":"group2",	"label":"group2",	"kind":"group",	"status":"pass"},
+		{"id":"group2/test3",	"label":"test3",	"kind":"test",	"group":"group2",	"status":"pass"},
+		{"id":"group2/test4",	"label":"test4",	"kind":"test",	"group":"group2",	"status":"pass"}
+	],
 	"links":[
-		{"source": "test1","target": "group","value": 1},
-		{"source": "test2","target": "group","value": 1},
-		{"source": "test3","target": "group2","value": 1},
-		{"source": "test4","target": "group2","value": 1}
+		{"source":"group/test1","target":"group","value":1},
+		{"source":"group/test2","target":"group","value":1},
+		{"source":"group2/test3","target":"group2","value":1},
+		{"source":"group2/test4","target":"group2","value":1}
 	]}`
 
 	json = strings.ReplaceAll(json, "\t", "")
@@ -108,6 +119,7 @@ func TestTwoGrops(t *testing.T) {
 func TestAfterCreatesLink(t *testing.T) {
 	rt := runner.NewT(t)
 	r := runner.New()
+	r.TimeProvider(frozen)
 	r.Group("group")
 	r.Append(rt, "test", func(*runner.T) {})
 	r.Append(rt, "test2", func(*runner.T) {})
@@ -118,21 +130,22 @@ func TestAfterCreatesLink(t *testing.T) {
 	r.Append(rt, "test2", func(*runner.T) {})
 
 	json := `{
+		"version":2,
 		"commit":"test",
 		"message":"test",
 		"nodes":[
-		{"id":"group",	"status":"pass"},
-		{"id":"test",	"status":"pass"},
-		{"id":"test2",	"status":"pass"},
-		{"id":"group2",	"status":"pass"},
-		{"id":"test",	"status":"pass"},
-		{"id":"test2",	"status":"pass"}
+		{"id":"group",	"label":"group",	"kind":"group",	"status":"pass"},
+		{"id":"group/test",	"label":"test",	"kind":"test",	"group":"group",	"status":"pass"},
+		{"id":"group/test2",	"label":"test2",	"kind":"test",	"group":"group",	"status":"pass"},
+		{"id":"group2",	"label":"group2",	"kind":"group",	"status":"pass"},
+		{"id":"group2/test",	"label":"test",	"kind":"test",	"group":"group2",	"status":"pass"},
+		{"id":"group2/test2",	"label":"test2",	"kind":"test",	"group":"group2",	"status":"pass"}
 	],
 	"links":[
-		{"source":"test","target":"group","value":1},
-		{"source":"test2","target":"group","value":1},
-		{"source":"test","target":"group2","value":1},
-		{"source":"test2","target":"group2","value":1},
+		{"source":"group/test","target":"group","value":1},
+		{"source":"group/test2","target":"group","value":1},
+		{"source":"group2/test","target":"group2","value":1},
+		{"source":"group2/test2","target":"group2","value":1},
 		{"source":"group2","target":"group","value":3}
 	]}`
 
@@ -146,8 +159,124 @@ func TestAfterCreatesLink(t *testing.T) {
 	assert.Equal(t, json, r.JSON())
 }
 
+func TestTestNamedAfterItsGroup(t *testing.T) {
+	rt := runner.NewT(t)
+	r := runner.New()
+	r.TimeProvider(frozen)
+	r.Group("group")
+	r.Append(rt, "group", func(*runner.T) {})
+
+	json := `{
+		"version":2,
+		"commit":"test",
+		"message":"test",
+		"nodes":[
+		{"id":"group",	"label":"group",	"kind":"group",	"status":"pass"},
+		{"id":"group/group",	"label":"group",	"kind":"test",	"group":"group",	"status":"pass"}
+	],
+	"links":[
+		{"source":"group/group","target":"group","value":1}
+	]}`
+
+	json = strings.ReplaceAll(json, "\t", "")
+	json = strings.ReplaceAll(json, "\n", "")
+	json = strings.ReplaceAll(json, " ", "")
+
+	r.CommitInfoProvider(func() (string, string) {
+		return "test", "test"
+	})
+	assert.Equal(t, json, r.JSON())
+}
+
+func TestTestableByTBHelpers(t *testing.T) {
+	rt := runner.NewT(t)
+	r := runner.New()
+	r.TimeProvider(frozen)
+	r.CommitInfoProvider(func() (string, string) {
+		t.Error("the stable document does not need the commit")
+
+		return "", ""
+	})
+	r.Group("group")
+	r.Append(rt, "test", func(at *runner.T) {
+		requireName(at, "TestTestableByTBHelpers/test")
+		at.Skip("skipped on purpose")
+	})
+
+	json := `{
+		"version":2,
+		"nodes":[
+		{"id":"group",	"label":"group",	"kind":"group",	"status":"pass"},
+		{"id":"group/test",	"label":"test",	"kind":"test",	"group":"group",	"status":"skipped"}
+	],
+	"links":[
+		{"source":"group/test","target":"group","value":1}
+	]}`
+
+	json = strings.ReplaceAll(json, "\t", "")
+	json = strings.ReplaceAll(json, "\n", "")
+	json = strings.ReplaceAll(json, " ", "")
+
+	assert.Equal(t, json, r.StableJSON())
+}
+
+func TestRunStartsSubtest(t *testing.T) {
+	var ran bool
+
+	r := runner.New()
+	r.Group("group")
+	r.Append(runner.NewT(t), "test", func(at *runner.T) {
+		at.Run("sub", func(t *testing.T) {
+			requireName(t, "TestRunStartsSubtest/test/sub")
+			ran = true
+		})
+	})
+
+	assert.True(t, ran)
+}
+
+func TestHelperFailureIsReportedAtTheCallSite(t *testing.T) {
+	if os.Getenv("ARBOR_HELPER_FAILS") != "" {
+		_, _, line, _ := runtime.Caller(0)
+		failHelper(runner.NewT(t))
+
+		fmt.Printf("call site line %d\n", line+1)
+
+		return
+	}
+
+	cmd := exec.Command(os.Args[0], "-test.run=^TestHelperFailureIsReportedAtTheCallSite$", "-test.v")
+	cmd.Env = append(os.Environ(), "ARBOR_HELPER_FAILS=1")
+
+	b, _ := cmd.CombinedOutput()
+	out := string(b)
+
+	var line int
+
+	if i := strings.Index(out, "call site line "); assert.True(t, i >= 0, out) {
+		_, err := fmt.Sscanf(out[i:], "call site line %d", &line)
+		assert.NoError(t, err)
+	}
+
+	assert.Contains(t, out, fmt.Sprintf("runner_test.go:%d: helper failure", line))
+}
+
+func failHelper(tb testing.TB) {
+	tb.Helper()
+	tb.Error("helper failure")
+}
+
+func requireName(tb testing.TB, name string) {
+	tb.Helper()
+
+	if tb.Name() != name {
+		tb.Fatalf("expected name %q, got %q", name, tb.Name())
+	}
+}
+
 func TestAfterFailedGroup(t *testing.T) {
 	r := runner.New()
+	r.TimeProvider(frozen)
 
 	mock := &fakeT{}
 	rt := runner.NewT(mock)
@@ -163,21 +292,25 @@ func TestAfterFailedGroup(t *testing.T) {
 	r.Append(rt, "test2", func(*runner.T) {})
 
 	json := `{
+		"version":2,
 		"commit":"test",
 		"message":"test",
 		"nodes":[
-		{"id":"group",	"status":"fail"},
-		{"id":"test",	"status":"pass"},
-		{"id":"test2",	"status":"fail"},
-		{"id":"group2",	"status":"skip"},
-		{"id":"test",	"status":"skip"},
-		{"id":"test2",	"status":"skip"}
+		{"id":"group",	"label":"group",	"kind":"group",	"status":"fail"},
+		{"id":"group/test",	"label":"test",	"kind":"test",	"group":"group",	"status":"pass"},
+		{"id":"group/test2",	"label":"test2",	"kind":"test",	"group":"group",	"status":"fail"},
+		{"id":"group2",	"label":"group2",	"kind":"group",	"status":"skip",
+			"skippedBecause":["group","group/test2"]},
+		{"id":"group2/test",	"label":"test",	"kind":"test",	"group":"group2",	"status":"skip",
+			"skippedBecause":["group2","group","group/test2"]},
+		{"id":"group2/test2",	"label":"test2",	"kind":"test",	"group":"group2",	"status":"skip",
+			"skippedBecause":["group2","group","group/test2"]}
 	],
 	"links":[
-		{"source":"test","target":"group","value":1},
-		{"source":"test2","target":"group","value":1},
-		{"source":"test","target":"group2","value":1},
-		{"source":"test2","target":"group2","value":1},
+		{"source":"group/test","target":"group","value":1},
+		{"source":"group/test2","target":"group","value":1},
+		{"source":"group2/test","target":"group2","value":1},
+		{"source":"group2/test2","target":"group2","value":1},
 		{"source":"group2","target":"group","value":3}
 	]}`
 
@@ -191,7 +324,13 @@ func TestAfterFailedGroup(t *testing.T) {
 	assert.Equal(t, json, r.JSON())
 }
 
+func frozen() time.Time {
+	return time.Time{}
+}
+
 type fakeT struct {
+	testing.TB
+
 	fail bool
 }
 
@@ -207,9 +346,46 @@ func (f *fakeT) Errorf(format string, args ...interface{}) {
 	f.fail = true
 }
 
+func (f *fakeT) Fail() {
+	f.fail = true
+}
+
+func (f *fakeT) Helper() {
+}
+
 func (f *fakeT) Log(args ...interface{}) {
 }
 
 func (f *fakeT) Run(_ string, _ func(t *testing.T)) bool {
 	return true
 }
+
+func (f *fakeT) Skip(args ...interface{}) {
+}
+
+func (f *fakeT) Cleanup(cf func()) {
+	cf()
+}
+
+func TestRunFollowsThePlan(t *testing.T) {
+	var ran []string
+
+	r := runner.New()
+	r.Plan(
+		runner.PlannedGroup{Name: "b", Tests: []string{"two"}},
+		runner.PlannedGroup{Name: "a", After: []string{"b"}, Tests: []string{"one"}},
+	)
+
+	for _, name := range []string{"a", "b"} {
+		name := name
+
+		r.Define(name, func(at *runner.T) {
+			ran = append(ran, at.Name())
+			r.Append(at, "test", func(*runner.T) {})
+		})
+	}
+
+	r.Run(t)
+
+	assert.Equal(t, []string{"TestRunFollowsThePlan/b", "TestRunFollowsThePlan/a"}, ran)
+}
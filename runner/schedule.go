@@ -0,0 +1,168 @@
+package runner
+
+import (
+	"flag"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/anatollupacescu/arbortest/report"
+)
+
+//nolint:gochecknoglobals	//idiomatic way of working with flags in Go
+var (
+	historyFiles = flag.String("arbor.history", "",
+		"comma separated run documents of previous runs, the groups heading the longest chains run first")
+	failFast = flag.Bool("arbor.fail-fast", false,
+		"with -arbor.history, run the groups which failed most often first")
+)
+
+// history keeps what previous runs tell about each group.
+type history struct {
+	durations map[string]time.Duration
+	failures  map[string]float64
+}
+
+func loadHistory(paths string) (*history, error) {
+	runs := make([]report.Run, 0)
+
+	for _, path := range strings.Split(paths, ",") {
+		run, err := readRun(path)
+		if err != nil {
+			return nil, err
+		}
+
+		runs = append(runs, run)
+	}
+
+	return newHistory(runs), nil
+}
+
+// newHistory averages the durations of the groups and computes how often they failed.
+func newHistory(runs []report.Run) *history {
+	h := &history{
+		durations: make(map[string]time.Duration),
+		failures:  make(map[string]float64),
+	}
+
+	seen := make(map[string]int)
+	failed := make(map[string]int)
+
+	for _, run := range runs {
+		for _, n := range run.Nodes {
+			if n.Kind != report.KindGroup {
+				continue
+			}
+
+			seen[n.ID]++
+			h.durations[n.ID] += n.Duration
+
+			if n.Status == report.StatusFail {
+				failed[n.ID]++
+			}
+		}
+	}
+
+	for id, n := range seen {
+		h.durations[id] /= time.Duration(n)
+		h.failures[id] = float64(failed[id]) / float64(n)
+	}
+
+	return h
+}
+
+// schedule orders the groups so that, among those whose dependencies already
+// ran, the one heading the longest chain of groups runs first, or with failFast
+// the one which failed most often. Groups unknown to the history take no time.
+func (h *history) schedule(plan []PlannedGroup, failFast bool) []PlannedGroup {
+	dependents := make(map[string][]string)
+
+	for _, p := range plan {
+		for _, dep := range p.After {
+			dependents[dep] = append(dependents[dep], p.Name)
+		}
+	}
+
+	// chain is the duration of the longest chain of groups starting with the group
+	chain := make(map[string]time.Duration, len(plan))
+
+	var length func(name string, visiting map[string]bool) time.Duration
+
+	length = func(name string, visiting map[string]bool) time.Duration {
+		if d, ok := chain[name]; ok {
+			return d
+		}
+
+		if visiting[name] {
+			// a cycle, arbor rejects them
+			return 0
+		}
+
+		visiting[name] = true
+
+		var longest time.Duration
+
+		for _, d := range dependents[name] {
+			if l := length(d, visiting); l > longest {
+				longest = l
+			}
+		}
+
+		chain[name] = h.durations[name] + longest
+
+		return chain[name]
+	}
+
+	for _, p := range plan {
+		length(p.Name, make(map[string]bool))
+	}
+
+	planned := make(map[string]bool, len(plan))
+	for _, p := range plan {
+		planned[p.Name] = true
+	}
+
+	done := make(map[string]bool, len(plan))
+	order := make([]PlannedGroup, 0, len(plan))
+
+	for len(order) < len(plan) {
+		var ready []PlannedGroup
+
+		for _, p := range plan {
+			if !done[p.Name] && depsDone(p, planned, done) {
+				ready = append(ready, p)
+			}
+		}
+
+		if len(ready) == 0 {
+			return append(order, remaining(plan, done)...)
+		}
+
+		sort.SliceStable(ready, func(i, j int) bool {
+			a, b := ready[i].Name, ready[j].Name
+
+			if failFast && h.failures[a] != h.failures[b] {
+				return h.failures[a] > h.failures[b]
+			}
+
+			return chain[a] > chain[b]
+		})
+
+		done[ready[0].Name] = true
+		order = append(order, ready[0])
+	}
+
+	return order
+}
+
+func remaining(plan []PlannedGroup, done map[string]bool) []PlannedGroup {
+	var rest []PlannedGroup
+
+	for _, p := range plan {
+		if !done[p.Name] {
+			rest = append(rest, p)
+		}
+	}
+
+	return rest
+}
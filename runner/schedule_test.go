@@ -0,0 +1,61 @@
+package runner
+
+import (
+	"testing"
+	"time"
+
+	"github.com/anatollupacescu/arbortest/report"
+	"github.com/stretchr/testify/assert"
+)
+
+func groupNodes(statuses map[string]string, durations map[string]time.Duration) report.Run {
+	var run report.Run
+
+	for id, s := range statuses {
+		run.Nodes = append(run.Nodes, report.Node{ID: id, Kind: report.KindGroup, Status: s, Duration: durations[id]})
+	}
+
+	return run
+}
+
+func TestNewHistory(t *testing.T) {
+	h := newHistory([]report.Run{
+		groupNodes(map[string]string{"a": report.StatusPass, "b": report.StatusFail},
+			map[string]time.Duration{"a": time.Second, "b": 3 * time.Second}),
+		groupNodes(map[string]string{"a": report.StatusPass, "b": report.StatusPass},
+			map[string]time.Duration{"a": 3 * time.Second, "b": time.Second}),
+	})
+
+	assert.Equal(t, map[string]time.Duration{"a": 2 * time.Second, "b": 2 * time.Second}, h.durations)
+	assert.Equal(t, map[string]float64{"a": 0, "b": 0.5}, h.failures)
+}
+
+func TestScheduleStartsLongestChainsFirst(t *testing.T) {
+	plan := []PlannedGroup{
+		{Name: "quick"},
+		{Name: "setup"},
+		{Name: "slow", After: []string{"setup"}},
+		{Name: "flaky"},
+	}
+
+	h := &history{
+		durations: map[string]time.Duration{
+			"quick": time.Second,
+			"setup": time.Second,
+			"slow":  time.Minute,
+			"flaky": 2 * time.Second,
+		},
+		failures: map[string]float64{"flaky": 0.5},
+	}
+
+	assert.Equal(t, []string{"setup", "slow", "flaky", "quick"}, names(h.schedule(plan, false)))
+	assert.Equal(t, []string{"flaky", "setup", "slow", "quick"}, names(h.schedule(plan, true)))
+}
+
+func TestScheduleKeepsPlanOrderWithoutHistory(t *testing.T) {
+	plan := []PlannedGroup{{Name: "b"}, {Name: "a"}, {Name: "c", After: []string{"a"}}}
+
+	h := newHistory(nil)
+
+	assert.Equal(t, []string{"b", "a", "c"}, names(h.schedule(plan, true)))
+}
@@ -0,0 +1,354 @@
+package runner
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/anatollupacescu/arbortest/report"
+)
+
+//nolint:gochecknoglobals	//idiomatic way of working with flags in Go
+var shardFlag = flag.String("arbor.shard", "",
+	"run a part of the groups only, 2/4 runs the second of four parts")
+
+var errBadShard = errors.New("bad shard")
+
+// shard is the part of the graph a run covers.
+type shard struct {
+	index, total int
+	// runs lists the groups the shard runs, owned the ones it is responsible
+	// for, the difference are the groups other shards need as well.
+	runs, owned map[string]bool
+}
+
+func parseShard(s string) (*shard, error) {
+	parts := strings.Split(s, "/")
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("%w: %q is not index/total", errBadShard, s)
+	}
+
+	index, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return nil, fmt.Errorf("%w: %q is not index/total", errBadShard, s)
+	}
+
+	total, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("%w: %q is not index/total", errBadShard, s)
+	}
+
+	if index < 1 || index > total {
+		return nil, fmt.Errorf("%w: %d is not between 1 and %d", errBadShard, index, total)
+	}
+
+	return &shard{index: index, total: total}, nil
+}
+
+// assign picks the groups of the shard, every shard computes the same
+// partition as long as they are given the same plan and history.
+func (s *shard) assign(plan []PlannedGroup, h *history) {
+	shards := partition(plan, weigher(plan, h), s.total)
+
+	s.runs = shards[s.index-1]
+	s.owned = make(map[string]bool)
+
+	for _, p := range plan {
+		for i, runs := range shards {
+			if runs[p.Name] {
+				if i == s.index-1 {
+					s.owned[p.Name] = true
+				}
+
+				break
+			}
+		}
+	}
+}
+
+func (s *shard) filter(plan []PlannedGroup) []PlannedGroup {
+	var part []PlannedGroup
+
+	for _, p := range plan {
+		if s.runs[p.Name] {
+			part = append(part, p)
+		}
+	}
+
+	return part
+}
+
+func (s *shard) document(plan []PlannedGroup) *report.Shard {
+	groups := make([]string, 0, len(s.owned))
+
+	for _, p := range plan {
+		if s.owned[p.Name] {
+			groups = append(groups, p.Name)
+		}
+	}
+
+	return &report.Shard{Index: s.index, Total: s.total, Groups: groups}
+}
+
+// weigher tells how long a group takes, from the history when known,
+// counting a second per test otherwise.
+func weigher(plan []PlannedGroup, h *history) func(string) time.Duration {
+	tests := make(map[string]int, len(plan))
+	for _, p := range plan {
+		tests[p.Name] = len(p.Tests)
+	}
+
+	return func(name string) time.Duration {
+		if h != nil {
+			if d, ok := h.durations[name]; ok {
+				return d
+			}
+		}
+
+		if tests[name] == 0 {
+			return time.Second
+		}
+
+		return time.Duration(tests[name]) * time.Second
+	}
+}
+
+// unit is a set of groups which runs on a single shard, along with all the
+// groups it depends on.
+type unit []string
+
+func (u unit) cost(weight func(string) time.Duration) time.Duration {
+	var d time.Duration
+
+	for _, name := range u {
+		d += weight(name)
+	}
+
+	return d
+}
+
+// partition splits the plan into the groups each shard runs. Groups connected
+// by dependencies stay together when possible, otherwise the groups nothing
+// depends on are split among shards, each of them taking the groups they
+// depend on along, so that the shards take about the same time. Shards left
+// without a group of their own re-run the cheapest one.
+func partition(plan []PlannedGroup, weight func(string) time.Duration, total int) []map[string]bool {
+	units := components(plan)
+
+	var sum time.Duration
+	for _, p := range plan {
+		sum += weight(p.Name)
+	}
+
+	ideal := sum / time.Duration(total)
+
+	for {
+		sort.SliceStable(units, func(i, j int) bool {
+			return units[i].cost(weight) > units[j].cost(weight)
+		})
+
+		split := -1
+
+		for i, u := range units {
+			if len(units) >= total && u.cost(weight) <= ideal {
+				break
+			}
+
+			if len(sinks(plan, u)) > 1 {
+				split = i
+
+				break
+			}
+		}
+
+		if split < 0 {
+			break
+		}
+
+		u := units[split]
+		units = append(units[:split], units[split+1:]...)
+
+		for _, sink := range sinks(plan, u) {
+			units = append(units, closure(plan, sink))
+		}
+	}
+
+	shards := make([]map[string]bool, total)
+	loads := make([]time.Duration, total)
+
+	for i := range shards {
+		shards[i] = make(map[string]bool)
+	}
+
+	for _, u := range units {
+		best, bestLoad := 0, time.Duration(-1)
+
+		for i := range shards {
+			load := loads[i]
+
+			for _, name := range u {
+				if !shards[i][name] {
+					load += weight(name)
+				}
+			}
+
+			if bestLoad < 0 || load < bestLoad {
+				best, bestLoad = i, load
+			}
+		}
+
+		for _, name := range u {
+			shards[best][name] = true
+		}
+
+		loads[best] = bestLoad
+	}
+
+	// with fewer units than shards some are left without a group, they re-run
+	// the cheapest group along with what it depends on, owned by another
+	// shard, so that they still upload a run the server can merge
+	spare := cheapest(plan, weight)
+
+	for i := range shards {
+		if len(shards[i]) == 0 {
+			for _, name := range spare {
+				shards[i][name] = true
+			}
+		}
+	}
+
+	return shards
+}
+
+// cheapest returns the group which takes the least time to run along with
+// the groups it depends on.
+func cheapest(plan []PlannedGroup, weight func(string) time.Duration) unit {
+	var best unit
+
+	for _, p := range plan {
+		if u := closure(plan, p.Name); best == nil || u.cost(weight) < best.cost(weight) {
+			best = u
+		}
+	}
+
+	return best
+}
+
+// components groups the plan by the dependencies connecting groups, in plan order.
+func components(plan []PlannedGroup) []unit {
+	root := make(map[string]string, len(plan))
+
+	var find func(string) string
+
+	find = func(name string) string {
+		if root[name] == name {
+			return name
+		}
+
+		root[name] = find(root[name])
+
+		return root[name]
+	}
+
+	for _, p := range plan {
+		root[p.Name] = p.Name
+	}
+
+	for _, p := range plan {
+		for _, dep := range p.After {
+			if _, ok := root[dep]; ok {
+				root[find(p.Name)] = find(dep)
+			}
+		}
+	}
+
+	var (
+		units []unit
+		index = make(map[string]int)
+	)
+
+	for _, p := range plan {
+		r := find(p.Name)
+
+		i, ok := index[r]
+		if !ok {
+			i = len(units)
+			index[r] = i
+			units = append(units, nil)
+		}
+
+		units[i] = append(units[i], p.Name)
+	}
+
+	return units
+}
+
+// sinks returns the groups of u no other group of u depends on.
+func sinks(plan []PlannedGroup, u unit) []string {
+	in := make(map[string]bool, len(u))
+	for _, name := range u {
+		in[name] = true
+	}
+
+	needed := make(map[string]bool)
+
+	for _, p := range plan {
+		if in[p.Name] {
+			for _, dep := range p.After {
+				needed[dep] = true
+			}
+		}
+	}
+
+	var ss []string
+
+	for _, name := range u {
+		if !needed[name] {
+			ss = append(ss, name)
+		}
+	}
+
+	return ss
+}
+
+// closure returns the group along with all the groups it depends on, in plan order.
+func closure(plan []PlannedGroup, name string) unit {
+	needed := map[string]bool{name: true}
+	queue := []string{name}
+
+	for len(queue) > 0 {
+		p, _ := planned(plan, queue[0])
+		queue = queue[1:]
+
+		for _, dep := range p.After {
+			if !needed[dep] {
+				needed[dep] = true
+				queue = append(queue, dep)
+			}
+		}
+	}
+
+	var u unit
+
+	for _, p := range plan {
+		if needed[p.Name] {
+			u = append(u, p.Name)
+		}
+	}
+
+	return u
+}
+
+func planned(plan []PlannedGroup, name string) (PlannedGroup, bool) {
+	for _, p := range plan {
+		if p.Name == name {
+			return p, true
+		}
+	}
+
+	return PlannedGroup{}, false
+}
@@ -0,0 +1,140 @@
+package runner
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/anatollupacescu/arbortest/report"
+)
+
+func second(string) time.Duration {
+	return time.Second
+}
+
+func members(shards []map[string]bool) [][]string {
+	all := make([][]string, 0, len(shards))
+
+	for _, s := range shards {
+		var nn []string
+
+		for _, n := range []string{"a", "b", "c", "d", "e", "root", "x", "y"} {
+			if s[n] {
+				nn = append(nn, n)
+			}
+		}
+
+		all = append(all, nn)
+	}
+
+	return all
+}
+
+func TestPartitionKeepsConnectedGroupsTogether(t *testing.T) {
+	plan := []PlannedGroup{
+		{Name: "a"},
+		{Name: "b", After: []string{"a"}},
+		{Name: "c"},
+		{Name: "d", After: []string{"c"}},
+		{Name: "e"},
+	}
+
+	assert.Equal(t, []unit{{"a", "b"}, {"c", "d"}, {"e"}}, components(plan))
+	assert.Equal(t, [][]string{{"a", "b", "e"}, {"c", "d"}}, members(partition(plan, second, 2)))
+}
+
+func TestPartitionRunsSharedAncestorsInEachShard(t *testing.T) {
+	plan := []PlannedGroup{
+		{Name: "root"},
+		{Name: "x", After: []string{"root"}},
+		{Name: "y", After: []string{"root"}},
+	}
+
+	assert.Equal(t, [][]string{{"root", "x"}, {"root", "y"}}, members(partition(plan, second, 2)))
+
+	s := &shard{index: 2, total: 2}
+	s.assign(plan, nil)
+
+	assert.Equal(t, &report.Shard{Index: 2, Total: 2, Groups: []string{"y"}}, s.document(plan))
+}
+
+func TestPartitionWithMoreShardsThanUnits(t *testing.T) {
+	plan := []PlannedGroup{
+		{Name: "root", Tests: []string{"one"}},
+		{Name: "x", After: []string{"root"}},
+		{Name: "y", After: []string{"x"}},
+	}
+
+	assert.Equal(t, [][]string{{"root", "x", "y"}, {"root"}, {"root"}}, members(partition(plan, second, 3)))
+
+	g := New()
+	g.TimeProvider(frozen)
+	g.CommitInfoProvider(func() (string, string) { return "", "" })
+	g.shard = &shard{index: 3, total: 3}
+	g.Plan(plan...)
+
+	g.Define("root", func(at *T) {
+		g.Append(at, "one", func(at *T) {})
+	})
+
+	g.Run(t)
+
+	run := document(*g)
+
+	assert.Equal(t, []report.Node{
+		{ID: "root", Label: "root", Kind: report.KindGroup, Status: report.StatusPass},
+		{ID: "root/one", Label: "one", Kind: report.KindTest, Group: "root", Status: report.StatusPass},
+	}, run.Nodes)
+	assert.Equal(t, &report.Shard{Index: 3, Total: 3, Groups: []string{}}, run.Shard)
+	assert.NoError(t, run.Validate())
+}
+
+func TestPartitionBalancesByDuration(t *testing.T) {
+	plan := []PlannedGroup{{Name: "a"}, {Name: "b"}, {Name: "c"}}
+	h := &history{durations: map[string]time.Duration{"a": time.Second, "b": time.Second, "c": time.Minute}}
+
+	assert.Equal(t, [][]string{{"c"}, {"a", "b"}}, members(partition(plan, weigher(plan, h), 2)))
+}
+
+func TestShardedRun(t *testing.T) {
+	g := New()
+	g.TimeProvider(frozen)
+	g.CommitInfoProvider(func() (string, string) { return "", "" })
+	g.shard = &shard{index: 1, total: 2}
+	g.Plan(
+		PlannedGroup{Name: "a", Tests: []string{"one"}},
+		PlannedGroup{Name: "b", Tests: []string{"one"}},
+	)
+
+	for _, name := range []string{"a", "b"} {
+		g.Define(name, func(at *T) {
+			g.Append(at, "one", func(at *T) {})
+		})
+	}
+
+	g.Run(t)
+
+	run := document(*g)
+
+	assert.Equal(t, []report.Node{
+		{ID: "a", Label: "a", Kind: report.KindGroup, Status: report.StatusPass},
+		{ID: "a/one", Label: "one", Kind: report.KindTest, Group: "a", Status: report.StatusPass},
+	}, run.Nodes)
+	assert.Equal(t, &report.Shard{Index: 1, Total: 2, Groups: []string{"a"}}, run.Shard)
+	assert.NoError(t, run.Validate())
+
+	assert.Equal(t, "arbor: 1 passed, 0 failed, 0 skipped because of failed dependencies\nshard 1/2 responsible for: a", g.Summary())
+}
+
+func TestParseShard(t *testing.T) {
+	s, err := parseShard("2/4")
+	assert.NoError(t, err)
+	assert.Equal(t, &shard{index: 2, total: 4}, s)
+
+	_, err = parseShard("2")
+	assert.EqualError(t, err, `bad shard: "2" is not index/total`)
+
+	_, err = parseShard("5/4")
+	assert.EqualError(t, err, "bad shard: 5 is not between 1 and 4")
+}
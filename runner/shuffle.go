@@ -0,0 +1,164 @@
+package runner
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//nolint:gochecknoglobals	//idiomatic way of working with flags in Go
+var (
+	shuffleMode = flag.String("arbor.shuffle", "off",
+		"randomize the order of the groups within their dependencies: off, on or the seed to use")
+	shuffleRuns = flag.Int("arbor.shuffle-runs", 1,
+		"run the groups this many times, each in a different order, and report the groups failing only in some of them")
+)
+
+var errBadShuffle = errors.New("bad shuffle")
+
+// shuffler hands out the seeds of the orders the groups run in.
+type shuffler struct {
+	enabled bool
+	seed    int64
+	runs    int
+	rng     *rand.Rand
+}
+
+func newShuffler(mode string, runs int) (*shuffler, error) {
+	if runs < 1 {
+		return nil, fmt.Errorf("%w: %d runs", errBadShuffle, runs)
+	}
+
+	s := &shuffler{runs: runs, enabled: runs > 1, seed: time.Now().UnixNano()}
+
+	switch mode {
+	case "off":
+	case "on":
+		s.enabled = true
+	default:
+		seed, err := strconv.ParseInt(mode, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q is neither off, on nor a seed", errBadShuffle, mode)
+		}
+
+		s.enabled = true
+		s.seed = seed
+	}
+
+	return s, nil
+}
+
+func (s *shuffler) rounds() int {
+	return s.runs
+}
+
+// next returns the seed of the next round, the first one is the seed asked for.
+func (s *shuffler) next() int64 {
+	if s.rng == nil {
+		s.rng = rand.New(rand.NewSource(s.seed)) //nolint:gosec	//the order does not need to be unpredictable
+
+		return s.seed
+	}
+
+	return s.rng.Int63()
+}
+
+// shuffled returns the groups in a random order in which every group still
+// comes after its dependencies, the same seed gives the same order.
+func shuffled(plan []PlannedGroup, seed int64) []PlannedGroup {
+	rng := rand.New(rand.NewSource(seed)) //nolint:gosec	//the order does not need to be unpredictable
+
+	planned := make(map[string]bool, len(plan))
+	for _, p := range plan {
+		planned[p.Name] = true
+	}
+
+	done := make(map[string]bool, len(plan))
+	order := make([]PlannedGroup, 0, len(plan))
+
+	for len(order) < len(plan) {
+		var ready []PlannedGroup
+
+		for _, p := range plan {
+			if !done[p.Name] && depsDone(p, planned, done) {
+				ready = append(ready, p)
+			}
+		}
+
+		if len(ready) == 0 {
+			// a cycle, arbor rejects them, keep the remaining groups as planned
+			return append(order, remaining(plan, done)...)
+		}
+
+		next := ready[rng.Intn(len(ready))]
+		done[next.Name] = true
+		order = append(order, next)
+	}
+
+	return order
+}
+
+func depsDone(p PlannedGroup, planned, done map[string]bool) bool {
+	for _, dep := range p.After {
+		if planned[dep] && !done[dep] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// round keeps the group statuses of one of the repeated runs.
+type round struct {
+	seed     int64
+	statuses map[string]status
+}
+
+func (g *Graph) endRound(seed int64) {
+	statuses := make(map[string]status, len(g.groups))
+	for _, grp := range g.groups {
+		statuses[grp.name] = grp.status
+	}
+
+	g.rounds = append(g.rounds, round{seed: seed, statuses: statuses})
+}
+
+// reset forgets the groups of the previous round, the last round is the one reported.
+func (g *Graph) reset() {
+	g.groupEnd()
+
+	g.groups = make(groups, 0)
+	g.deps = make(map[string][]string)
+	g.currentGroupName = ""
+}
+
+// orderDependent lists the groups which failed in some rounds only.
+func (g Graph) orderDependent() []string {
+	var found []string
+
+	for _, p := range g.plan {
+		var failed, passed []string
+
+		for _, r := range g.rounds {
+			seed := strconv.FormatInt(r.seed, 10)
+
+			switch r.statuses[p.Name] {
+			case fail:
+				failed = append(failed, seed)
+			case pass:
+				passed = append(passed, seed)
+			}
+		}
+
+		if len(failed) > 0 && len(passed) > 0 {
+			found = append(found, fmt.Sprintf("%s failed with seeds %s and passed with %s",
+				p.Name, strings.Join(failed, ", "), strings.Join(passed, ", ")))
+		}
+	}
+
+	return found
+}
@@ -0,0 +1,85 @@
+package runner
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func names(plan []PlannedGroup) []string {
+	nn := make([]string, 0, len(plan))
+	for _, p := range plan {
+		nn = append(nn, p.Name)
+	}
+
+	return nn
+}
+
+func TestShuffledKeepsDependenciesFirst(t *testing.T) {
+	plan := []PlannedGroup{
+		{Name: "a"},
+		{Name: "b", After: []string{"a"}},
+		{Name: "c"},
+		{Name: "d", After: []string{"b", "c"}},
+		{Name: "e"},
+	}
+
+	orders := make(map[string]bool)
+
+	for seed := int64(1); seed <= 50; seed++ {
+		order := names(shuffled(plan, seed))
+
+		assert.ElementsMatch(t, names(plan), order)
+
+		index := make(map[string]int)
+		for i, n := range order {
+			index[n] = i
+		}
+
+		assert.Less(t, index["a"], index["b"], order)
+		assert.Less(t, index["b"], index["d"], order)
+		assert.Less(t, index["c"], index["d"], order)
+
+		assert.Equal(t, order, names(shuffled(plan, seed)), "same seed, same order")
+
+		orders[strings.Join(order, " ")] = true
+	}
+
+	assert.Greater(t, len(orders), 1)
+}
+
+func TestNewShuffler(t *testing.T) {
+	s, err := newShuffler("off", 1)
+	assert.NoError(t, err)
+	assert.False(t, s.enabled)
+
+	s, err = newShuffler("42", 1)
+	assert.NoError(t, err)
+	assert.True(t, s.enabled)
+	assert.Equal(t, int64(42), s.next())
+	assert.NotEqual(t, int64(42), s.next())
+
+	s, err = newShuffler("off", 3)
+	assert.NoError(t, err)
+	assert.True(t, s.enabled, "repeated runs shuffle")
+
+	_, err = newShuffler("sometimes", 1)
+	assert.EqualError(t, err, `bad shuffle: "sometimes" is neither off, on nor a seed`)
+
+	_, err = newShuffler("on", 0)
+	assert.EqualError(t, err, "bad shuffle: 0 runs")
+}
+
+func TestOrderDependentGroups(t *testing.T) {
+	g := New()
+	g.Plan(PlannedGroup{Name: "a"}, PlannedGroup{Name: "b"}, PlannedGroup{Name: "c"})
+	g.rounds = []round{
+		{seed: 1, statuses: map[string]status{"a": pass, "b": fail, "c": fail}},
+		{seed: 2, statuses: map[string]status{"a": pass, "b": pass, "c": fail}},
+		{seed: 3, statuses: map[string]status{"a": pass, "b": fail, "c": fail}},
+	}
+
+	assert.Equal(t, []string{"b failed with seeds 1, 3 and passed with 2"}, g.orderDependent())
+	assert.Contains(t, g.Summary(), "\n3 runs in different orders\norder dependent: b failed with seeds 1, 3 and passed with 2")
+}
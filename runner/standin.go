@@ -0,0 +1,105 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"testing"
+)
+
+// standIn is the testing.TB of a T whose proxy is not one, it implements the
+// methods of testing.TB which Testable leaves out on top of the proxy, so that
+// helpers calling them do not end up on a nil testing.TB.
+type standIn struct {
+	// TB is nil, it only brings in the unexported method of testing.TB.
+	testing.TB
+
+	proxy  Testable
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func newStandIn(t Testable) *standIn {
+	return &standIn{proxy: t}
+}
+
+// Helper forwards to the proxy.
+func (s *standIn) Helper() {
+	s.proxy.Helper()
+}
+
+// Context is canceled once the test is over, before its cleanups run.
+func (s *standIn) Context() context.Context {
+	if s.ctx == nil {
+		s.ctx, s.cancel = context.WithCancel(context.Background())
+		s.proxy.Cleanup(s.cancel)
+	}
+
+	return s.ctx
+}
+
+// Setenv sets an environment variable until the test is over.
+func (s *standIn) Setenv(key, value string) {
+	s.proxy.Helper()
+
+	prev, ok := os.LookupEnv(key)
+
+	if err := os.Setenv(key, value); err != nil {
+		s.proxy.Fatalf("cannot set environment variable: %v", err)
+	}
+
+	s.proxy.Cleanup(func() {
+		if ok {
+			_ = os.Setenv(key, prev)
+		} else {
+			_ = os.Unsetenv(key)
+		}
+	})
+}
+
+// Chdir changes the working directory until the test is over.
+func (s *standIn) Chdir(dir string) {
+	s.proxy.Helper()
+
+	wd, err := os.Getwd()
+	if err != nil {
+		s.proxy.Fatal(err)
+	}
+
+	if err := os.Chdir(dir); err != nil {
+		s.proxy.Fatal(err)
+	}
+
+	s.proxy.Cleanup(func() {
+		_ = os.Chdir(wd)
+	})
+}
+
+// Output logs what is written to it, line by line.
+func (s *standIn) Output() io.Writer {
+	return logWriter{s.proxy}
+}
+
+// Attr logs the attribute.
+func (s *standIn) Attr(key, value string) {
+	s.proxy.Log(fmt.Sprintf("%s: %s", key, value))
+}
+
+// ArtifactDir returns a temporary directory, the proxy keeps no artifacts.
+func (s *standIn) ArtifactDir() string {
+	return s.proxy.TempDir()
+}
+
+type logWriter struct {
+	t Testable
+}
+
+func (w logWriter) Write(p []byte) (int, error) {
+	for _, l := range strings.Split(strings.TrimSuffix(string(p), "\n"), "\n") {
+		w.t.Log(l)
+	}
+
+	return len(p), nil
+}
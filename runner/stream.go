@@ -0,0 +1,184 @@
+package runner
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/anatollupacescu/arbortest/report"
+)
+
+//nolint:gochecknoglobals	//idiomatic way of working with flags in Go
+var live = flag.String("arbor.live", "", "arbor server url to stream the progress of the run to, e.g. http://localhost:3000/progress/")
+
+const (
+	streamTimeout = 5 * time.Second
+	// streamBuffer is how many events wait to be sent, updates coming while
+	// it is full are dropped rather than holding up the tests.
+	streamBuffer = 1024
+)
+
+// streamer is the Reporter sending the progress of the run to the UI server as
+// it happens, the events are sent in order from a goroutine of their own.
+type streamer struct {
+	url    string
+	run    string
+	client http.Client
+	events chan report.Progress
+	done   chan struct{}
+	// dropped counts the updates left out because the server was too slow.
+	dropped int
+	// broken stops the streaming after the first failure, the run goes on regardless.
+	broken bool
+}
+
+func newStreamer(url string) *streamer {
+	s := &streamer{
+		url:    url,
+		run:    strconv.FormatInt(time.Now().UnixNano(), 36),
+		client: http.Client{Timeout: streamTimeout},
+		events: make(chan report.Progress, streamBuffer),
+		done:   make(chan struct{}),
+	}
+
+	go s.loop()
+
+	return s
+}
+
+func (s *streamer) loop() {
+	defer close(s.done)
+
+	for p := range s.events {
+		if s.broken {
+			continue
+		}
+
+		if err := s.post(p); err != nil {
+			s.broken = true
+
+			log.Printf("stream test progress: %s, streaming stopped", err)
+		}
+	}
+}
+
+func (s *streamer) post(p report.Progress) error {
+	data, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+
+	r, err := s.client.Post(s.url, "application/json", bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+
+	_ = r.Body.Close()
+
+	if r.StatusCode != http.StatusOK {
+		return fmt.Errorf("bad response status: %s", r.Status)
+	}
+
+	return nil
+}
+
+func (s *streamer) send(event string, nodes []report.Node, links []report.Link) {
+	select {
+	case s.events <- s.progress(event, nodes, links):
+	default:
+		s.dropped++
+	}
+}
+
+func (s *streamer) progress(event string, nodes []report.Node, links []report.Link) report.Progress {
+	return report.Progress{
+		Run:   s.run,
+		Event: event,
+		Nodes: nodes,
+		Links: links,
+	}
+}
+
+func (s *streamer) RunStart(plan report.Run) {
+	s.send(report.ProgressStart, plan.Nodes, plan.Links)
+}
+
+// RunEnd waits for the events sent so far, for a while, the end of the run is never dropped.
+func (s *streamer) RunEnd(run report.Run) {
+	s.events <- s.progress(report.ProgressEnd, run.Nodes, run.Links)
+	close(s.events)
+
+	if s.dropped > 0 {
+		log.Printf("stream test progress: %d updates dropped, the server was too slow", s.dropped)
+	}
+
+	select {
+	case <-s.done:
+	case <-time.After(streamTimeout):
+		log.Printf("stream test progress: gave up waiting for the server after %s", streamTimeout)
+	}
+}
+
+func (s *streamer) GroupStart(name string) {
+	s.send(report.ProgressUpdate, []report.Node{groupNode(name, report.StatusRunning, nil)}, nil)
+}
+
+func (s *streamer) GroupSkip(name string, because []string) {
+	s.send(report.ProgressUpdate, []report.Node{groupNode(name, report.StatusSkip, because)}, nil)
+}
+
+func (s *streamer) GroupEnd(result GroupResult) {
+	node := groupNode(result.Name, result.Status, result.SkippedBecause)
+	node.Duration = result.Duration
+
+	links := make([]report.Link, 0, len(result.Deps))
+	for _, dep := range result.Deps {
+		links = append(links, report.Link{Source: result.Name, Target: dep, Value: report.GroupLink})
+	}
+
+	s.send(report.ProgressUpdate, []report.Node{node}, links)
+}
+
+func (s *streamer) TestStart(group, name string) {
+	node := testNode(group, name, report.StatusRunning)
+
+	s.send(report.ProgressUpdate, []report.Node{node}, []report.Link{testLink(node)})
+}
+
+func (s *streamer) TestEnd(result TestResult) {
+	node := testNode(result.Group, result.Name, result.Status)
+	node.Duration = result.Duration
+	node.SkippedBecause = result.SkippedBecause
+
+	s.send(report.ProgressUpdate, []report.Node{node}, []report.Link{testLink(node)})
+}
+
+func groupNode(name, status string, because []string) report.Node {
+	return report.Node{
+		ID:     name,
+		Label:  name,
+		Kind:   report.KindGroup,
+		Status: status,
+
+		SkippedBecause: because,
+	}
+}
+
+func testNode(group, name, status string) report.Node {
+	return report.Node{
+		ID:     report.TestID(group, name),
+		Label:  name,
+		Kind:   report.KindTest,
+		Group:  group,
+		Status: status,
+	}
+}
+
+func testLink(n report.Node) report.Link {
+	return report.Link{Source: n.ID, Target: n.Group, Value: report.TestLink}
+}
@@ -0,0 +1,123 @@
+package runner
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/anatollupacescu/arbortest/report"
+)
+
+func TestStreamerSendsProgress(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		received []report.Progress
+	)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p, err := report.DecodeProgress(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+
+			return
+		}
+
+		mu.Lock()
+		received = append(received, p)
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	s := newStreamer(srv.URL)
+
+	g := New()
+	g.TimeProvider(frozen)
+	g.CommitInfoProvider(func() (string, string) { return "", "" })
+	g.Report(s)
+
+	at := NewT(&fakeT{})
+	g.Group("a")
+	g.Append(at, "test", func(at *T) {})
+	g.Group("b")
+	g.After(at, "a")
+	g.End()
+
+	var events []string
+
+	for _, p := range received {
+		assert.Equal(t, s.run, p.Run)
+
+		for _, n := range p.Nodes {
+			events = append(events, p.Event+" "+n.ID+" "+n.Status)
+		}
+	}
+
+	expected := []string{
+		"update a running",
+		"update a/test running",
+		"update a/test pass",
+		"update a pass",
+		"update b running",
+		"update b pass",
+		"end a pass",
+		"end a/test pass",
+		"end b pass",
+	}
+
+	assert.Equal(t, report.ProgressStart, received[0].Event)
+	assert.Equal(t, expected, events)
+	assert.Equal(t, []report.Link{{Source: "b", Target: "a", Value: report.GroupLink}}, received[6].Links)
+
+	data, err := json.Marshal(received[len(received)-1].Nodes)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(data), report.StatusRunning)
+}
+
+func TestStreamerStopsAfterFailure(t *testing.T) {
+	var calls int
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+
+		http.Error(w, "nope", http.StatusBadRequest)
+	}))
+	defer srv.Close()
+
+	s := newStreamer(srv.URL)
+	s.RunStart(report.Run{})
+	s.GroupStart("a")
+	s.RunEnd(report.Run{})
+
+	assert.True(t, s.broken)
+	assert.Equal(t, 1, calls)
+}
+
+func TestStreamerDoesNotWaitForTheServer(t *testing.T) {
+	var calls int32
+
+	release := make(chan struct{})
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+
+		atomic.AddInt32(&calls, 1)
+	}))
+	defer srv.Close()
+
+	s := newStreamer(srv.URL)
+	s.RunStart(report.Run{})
+	s.GroupStart("a")
+
+	// the server did not answer yet
+	assert.Equal(t, int32(0), atomic.LoadInt32(&calls))
+
+	close(release)
+	s.RunEnd(report.Run{})
+
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+}
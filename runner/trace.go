@@ -0,0 +1,184 @@
+package runner
+
+import (
+	"encoding/json"
+	"flag"
+	"io/ioutil"
+	"log"
+	"path/filepath"
+	"time"
+
+	"github.com/anatollupacescu/arbortest/report"
+)
+
+//nolint:gochecknoglobals	//idiomatic way of working with flags in Go
+var traceFile = flag.String("arbor.trace", "", "write the run as a Chrome trace event file, to open in a trace viewer such as Perfetto")
+
+// traceEvent is an event of the Chrome trace event format, timestamps are in microseconds.
+type traceEvent struct {
+	Name string                 `json:"name"`
+	Cat  string                 `json:"cat,omitempty"`
+	Ph   string                 `json:"ph"`
+	Ts   int64                  `json:"ts"`
+	Dur  int64                  `json:"dur,omitempty"`
+	Pid  int                    `json:"pid"`
+	Tid  int                    `json:"tid"`
+	ID   int                    `json:"id,omitempty"`
+	Bp   string                 `json:"bp,omitempty"`
+	S    string                 `json:"s,omitempty"`
+	Args map[string]interface{} `json:"args,omitempty"`
+}
+
+type traceDocument struct {
+	TraceEvents     []traceEvent `json:"traceEvents"`
+	DisplayTimeUnit string       `json:"displayTimeUnit"`
+}
+
+// Trace event phases used by the tracer.
+const (
+	phaseComplete  = "X"
+	phaseInstant   = "i"
+	phaseFlowStart = "s"
+	phaseFlowEnd   = "f"
+	phaseMetadata  = "M"
+)
+
+// tracer is the Reporter writing the trace file, groups and tests are slices
+// nested on a single thread, dependencies are flows between group slices.
+type tracer struct {
+	NopReporter
+
+	path   string
+	now    func() time.Time
+	start  time.Time
+	events []traceEvent
+	// groupStarts and testStarts are the timestamps of the slices still open,
+	// groupEnds those of the groups done, where the flows to their dependents start.
+	groupStarts map[string]int64
+	testStarts  map[string]int64
+	groupEnds   map[string]int64
+	flows       int
+}
+
+func newTracer(path string, now func() time.Time) *tracer {
+	return &tracer{
+		path:        path,
+		now:         now,
+		groupStarts: make(map[string]int64),
+		testStarts:  make(map[string]int64),
+		groupEnds:   make(map[string]int64),
+	}
+}
+
+func (t *tracer) ts() int64 {
+	return t.now().Sub(t.start).Microseconds()
+}
+
+func (t *tracer) RunStart(report.Run) {
+	t.start = t.now()
+	t.events = append(t.events, traceEvent{
+		Name: "process_name",
+		Ph:   phaseMetadata,
+		Pid:  1,
+		Args: map[string]interface{}{"name": "arbor"},
+	})
+}
+
+func (t *tracer) GroupStart(name string) {
+	t.groupStarts[name] = t.ts()
+}
+
+func (t *tracer) GroupSkip(name string, because []string) {
+	t.events = append(t.events, traceEvent{
+		Name: "skipped " + name,
+		Cat:  report.KindGroup,
+		Ph:   phaseInstant,
+		Ts:   t.ts(),
+		Pid:  1,
+		Tid:  1,
+		S:    "t",
+		Args: map[string]interface{}{"skippedBecause": because},
+	})
+}
+
+func (t *tracer) GroupEnd(result GroupResult) {
+	start, end := t.groupStarts[result.Name], t.ts()
+	t.groupEnds[result.Name] = end
+
+	t.events = append(t.events, traceEvent{
+		Name: result.Name,
+		Cat:  report.KindGroup,
+		Ph:   phaseComplete,
+		Ts:   start,
+		Dur:  end - start,
+		Pid:  1,
+		Tid:  1,
+		Args: map[string]interface{}{"status": result.Status},
+	})
+
+	for _, dep := range result.Deps {
+		// the dependent can begin once the dependency is done
+		depEnd, ok := t.groupEnds[dep]
+		if !ok {
+			continue
+		}
+
+		t.flows++
+
+		t.events = append(t.events,
+			traceEvent{Name: "after", Cat: "dependency", Ph: phaseFlowStart, Ts: depEnd, Pid: 1, Tid: 1, ID: t.flows},
+			traceEvent{Name: "after", Cat: "dependency", Ph: phaseFlowEnd, Ts: start, Pid: 1, Tid: 1, ID: t.flows, Bp: "e"},
+		)
+	}
+}
+
+func (t *tracer) TestStart(group, name string) {
+	t.testStarts[report.TestID(group, name)] = t.ts()
+}
+
+func (t *tracer) TestEnd(result TestResult) {
+	id := report.TestID(result.Group, result.Name)
+
+	start, ok := t.testStarts[id]
+	if !ok {
+		// skipped without starting
+		t.events = append(t.events, traceEvent{
+			Name: "skipped " + result.Name,
+			Cat:  report.KindTest,
+			Ph:   phaseInstant,
+			Ts:   t.ts(),
+			Pid:  1,
+			Tid:  1,
+			S:    "t",
+			Args: map[string]interface{}{"status": result.Status, "skippedBecause": result.SkippedBecause},
+		})
+
+		return
+	}
+
+	t.events = append(t.events, traceEvent{
+		Name: result.Name,
+		Cat:  report.KindTest,
+		Ph:   phaseComplete,
+		Ts:   start,
+		Dur:  result.Duration.Microseconds(),
+		Pid:  1,
+		Tid:  1,
+		Args: map[string]interface{}{"group": result.Group, "status": result.Status},
+	})
+}
+
+func (t *tracer) RunEnd(report.Run) {
+	data, err := json.Marshal(traceDocument{TraceEvents: t.events, DisplayTimeUnit: "ms"})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if err := ioutil.WriteFile(filepath.Clean(t.path), data, 0600); err != nil {
+		log.Printf("write trace: %s", err)
+
+		return
+	}
+
+	log.Printf("write trace: %s", t.path)
+}
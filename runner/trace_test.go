@@ -0,0 +1,83 @@
+package runner
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func ticking() func() time.Time {
+	var now time.Time
+
+	return func() time.Time {
+		now = now.Add(time.Millisecond)
+
+		return now
+	}
+}
+
+func TestTraceNestsTestsInGroups(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "trace.json")
+
+	g := New()
+	g.TimeProvider(ticking())
+	g.CommitInfoProvider(func() (string, string) { return "", "" })
+	g.Report(newTracer(path, func() time.Time { return g.timeProvider() }))
+
+	at := NewT(&fakeT{})
+	g.Group("a")
+	g.Append(at, "pass", func(at *T) {})
+	g.Append(at, "fail", func(at *T) { at.Error("no") })
+
+	bt := NewT(&fakeT{})
+	g.Group("b")
+	g.After(bt, "a")
+	g.Append(bt, "never", func(at *T) {})
+	g.End()
+
+	data, err := ioutil.ReadFile(path)
+	assert.NoError(t, err)
+
+	var doc traceDocument
+
+	assert.NoError(t, json.Unmarshal(data, &doc))
+
+	slices := make(map[string]traceEvent)
+	phases := make(map[string][]string)
+
+	var flowStart, flowEnd traceEvent
+
+	for _, e := range doc.TraceEvents {
+		phases[e.Ph] = append(phases[e.Ph], e.Name)
+
+		switch e.Ph {
+		case phaseComplete:
+			slices[e.Name] = e
+		case phaseFlowStart:
+			flowStart = e
+		case phaseFlowEnd:
+			flowEnd = e
+		}
+	}
+
+	assert.ElementsMatch(t, []string{"a", "pass", "fail", "b"}, phases[phaseComplete])
+	assert.ElementsMatch(t, []string{"skipped b", "skipped never"}, phases[phaseInstant])
+	assert.Equal(t, []string{"after"}, phases[phaseFlowStart])
+	assert.Equal(t, []string{"after"}, phases[phaseFlowEnd])
+
+	within := func(inner, outer traceEvent) {
+		assert.GreaterOrEqual(t, inner.Ts, outer.Ts, inner.Name)
+		assert.LessOrEqual(t, inner.Ts+inner.Dur, outer.Ts+outer.Dur, inner.Name)
+	}
+
+	within(slices["pass"], slices["a"])
+	within(slices["fail"], slices["a"])
+	assert.GreaterOrEqual(t, slices["b"].Ts, slices["a"].Ts+slices["a"].Dur)
+	assert.Equal(t, "fail", slices["fail"].Args["status"])
+	assert.Equal(t, slices["a"].Ts+slices["a"].Dur, flowStart.Ts, "the flow starts where the dependency ends")
+	assert.Equal(t, slices["b"].Ts, flowEnd.Ts)
+}
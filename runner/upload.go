@@ -2,19 +2,52 @@ package runner
 
 import (
 	"flag"
+	"io/ioutil"
 	"log"
 	"net/http"
 	"net/url"
+	"os"
+	"strconv"
 	"strings"
 )
 
 //nolint:gochecknoglobals	//idiomatic way of working with flags in Go
-var uri = flag.String("arborURL", "", "arbor server url")
+var (
+	uri   = flag.String("arborURL", "", "arbor server url")
+	runID = flag.String("arbor.run-id", os.Getenv("ARBOR_RUN_ID"),
+		"id of the run the upload is a part of, the server merges the parts with the same id, defaults to $ARBOR_RUN_ID")
+	runParts = flag.Int("arbor.run-parts", envInt("ARBOR_RUN_PARTS"),
+		"how many parts the run is uploaded in, defaults to $ARBOR_RUN_PARTS or, when sharding, to the number of shards")
+	runPart = flag.String("arbor.run-part", os.Getenv("ARBOR_RUN_PART"),
+		"name of the part within the run, the server replaces a part uploaded again, defaults to $ARBOR_RUN_PART, shards are named after their index")
+	outFile = flag.String("arbor.out", "", "file to write the run document to, on top of uploading it")
+)
+
+// envInt reads a number from the environment, 0 when absent or malformed.
+func envInt(name string) int {
+	n, err := strconv.Atoi(os.Getenv(name))
+	if err != nil {
+		return 0
+	}
+
+	return n
+}
 
 // Upload sends the graph json to the UI server.
 func Upload(data string) {
 	flag.Parse()
 
+	if *dryRun {
+		log.Println("dry run, skipping upload...")
+		return
+	}
+
+	if *outFile != "" {
+		if err := ioutil.WriteFile(*outFile, []byte(data), 0600); err != nil {
+			log.Fatalf("write run document: %s", err)
+		}
+	}
+
 	if *uri == "" {
 		log.Println("server uri not provided, skipping upload...")
 		return
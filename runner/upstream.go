@@ -0,0 +1,78 @@
+package runner
+
+import (
+	"flag"
+	"strings"
+
+	"github.com/anatollupacescu/arbortest/report"
+)
+
+//nolint:gochecknoglobals	//idiomatic way of working with flags in Go
+var upstreamFile = flag.String("arbor.upstream", "",
+	"run document of the packages this one depends on, with ids qualified by package name, see arbortest run")
+
+// upstream holds the results of the groups of other packages.
+type upstream struct {
+	run   report.Run
+	nodes map[string]report.Node
+}
+
+func loadUpstream(path string) (*upstream, error) {
+	run, err := readRun(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return newUpstream(run), nil
+}
+
+func newUpstream(run report.Run) *upstream {
+	u := &upstream{run: run, nodes: make(map[string]report.Node, len(run.Nodes))}
+
+	for _, n := range run.Nodes {
+		u.nodes[n.ID] = n
+	}
+
+	return u
+}
+
+// external tells whether the id refers to a node of another package, their
+// groups are qualified by the package name, e.g. inventory.ingredient.
+func external(id string) bool {
+	return strings.Contains(id, ".")
+}
+
+// node returns the node as reported upstream, groups missing from the
+// upstream run did not run. Without upstream results nothing is known of
+// other packages, and their groups are left out.
+func (u *upstream) node(id string) (report.Node, bool) {
+	if u == nil {
+		return report.Node{}, false
+	}
+
+	n, ok := u.nodes[id]
+	if !ok {
+		return groupNode(id, report.StatusNotRun, nil), true
+	}
+
+	return n, true
+}
+
+// cause returns the chain of node ids explaining why the upstream group did
+// not pass, like group.cause does for the groups of this package.
+func (u *upstream) cause(id string) []string {
+	n, _ := u.node(id)
+
+	switch n.Status {
+	case report.StatusSkip:
+		return append([]string{id}, n.SkippedBecause...)
+	case report.StatusFail:
+		for _, t := range u.run.Nodes {
+			if t.Kind == report.KindTest && t.Group == id && t.Status == report.StatusFail {
+				return []string{id, t.ID}
+			}
+		}
+	}
+
+	return []string{id}
+}
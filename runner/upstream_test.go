@@ -0,0 +1,68 @@
+package runner
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/anatollupacescu/arbortest/report"
+)
+
+func TestGroupOfAnotherPackageFailed(t *testing.T) {
+	g := New()
+	g.TimeProvider(frozen)
+	g.CommitInfoProvider(func() (string, string) { return "", "" })
+	g.upstream = newUpstream(report.Run{Nodes: []report.Node{
+		{ID: "inventory.ingredient", Kind: report.KindGroup, Status: report.StatusFail},
+		{ID: "inventory.ingredient/Create", Label: "Create", Kind: report.KindTest, Group: "inventory.ingredient", Status: report.StatusFail},
+		{ID: "inventory.stock", Kind: report.KindGroup, Status: report.StatusPass},
+	}})
+
+	var counter int
+
+	at := NewT(&fakeT{})
+	g.Group("order")
+	g.After(at, "inventory.stock", "inventory.ingredient")
+	g.Append(at, "Place", func(at *T) {
+		counter++
+	})
+
+	assert.Equal(t, 0, counter)
+	assert.Equal(t, skip, g.groups.get("order").status)
+	assert.Equal(t, []string{"inventory.ingredient", "inventory.ingredient/Create"}, g.groups.get("order").skippedBecause)
+
+	run := document(*g)
+
+	assert.Equal(t, []report.Node{
+		{ID: "order", Label: "order", Kind: report.KindGroup, Status: report.StatusSkip,
+			SkippedBecause: []string{"inventory.ingredient", "inventory.ingredient/Create"}},
+		{ID: "order/Place", Label: "Place", Kind: report.KindTest, Group: "order", Status: report.StatusSkip,
+			SkippedBecause: []string{"order", "inventory.ingredient", "inventory.ingredient/Create"}},
+		{ID: "inventory.ingredient", Kind: report.KindGroup, Status: report.StatusFail},
+		{ID: "inventory.ingredient/Create", Label: "Create", Kind: report.KindTest, Group: "inventory.ingredient", Status: report.StatusFail},
+		{ID: "inventory.stock", Kind: report.KindGroup, Status: report.StatusPass},
+	}, run.Nodes)
+	assert.NoError(t, run.Validate())
+}
+
+func TestGroupsOfOtherPackagesAreIgnoredWithoutUpstream(t *testing.T) {
+	g := New()
+	g.TimeProvider(frozen)
+	g.CommitInfoProvider(func() (string, string) { return "", "" })
+
+	var counter int
+
+	at := NewT(&fakeT{})
+	g.Group("order")
+	g.After(at, "inventory.ingredient")
+	g.Append(at, "Place", func(at *T) {
+		counter++
+	})
+
+	assert.Equal(t, 1, counter)
+
+	run := document(*g)
+
+	assert.Len(t, run.Nodes, 2)
+	assert.Empty(t, run.Links[1:], "no link to the unknown group")
+}
@@ -1,53 +1,142 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
-	"io/ioutil"
 	"log"
 	"net/http"
+	"os"
+	"os/signal"
 	"strings"
+	"syscall"
+	"time"
 
 	packr "github.com/gobuffalo/packr/v2"
+
+	"github.com/anatollupacescu/arbortest/report"
 )
 
 func init() {
-	flag.Parse()
-
 	log.SetFlags(0)
 	log.SetPrefix("» ")
 }
 
+const (
+	shutdownTimeout     = 10 * time.Second
+	defaultMergeTimeout = 30 * time.Minute
+)
+
 //nolint:gochecknoglobals // idiomatic way of working with flags in Go
-var port = flag.Int("port", 3000, "port to listen to")
+var (
+	// serverFlags are the flags of the server, kept apart from flag.CommandLine
+	// where the runner, which ingest uses, registers the flags of the tests.
+	serverFlags = flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+
+	port         = serverFlags.Int("port", 3000, "port to listen to")
+	basePath     = serverFlags.String("base-path", "", "sub-path to serve the UI and API under, e.g. /arbor")
+	mergeTimeout = serverFlags.Duration("merge-timeout", defaultMergeTimeout,
+		"how long to wait for the missing parts of a run uploaded in parts before marking it complete")
+)
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "analyze" {
+		if err := analyze(os.Args[2:], os.Stdout); err != nil {
+			log.Fatal(err)
+		}
+
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "ingest" {
+		if err := ingest(os.Args[2:], os.Stdin, os.Stdout); err != nil {
+			log.Fatal(err)
+		}
+
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "run" {
+		if err := orchestrate(os.Args[2:], os.Stdout); err != nil {
+			log.Fatal(err)
+		}
+
+		return
+	}
+
+	_ = serverFlags.Parse(os.Args[1:])
+
 	if err := run(); err != nil {
 		log.Fatal(err)
 	}
 }
 
 func run() error {
-	b := &broker{
-		make(map[stringChan]struct{}),
-		make(chan (stringChan)),
-		make(chan (stringChan)),
-		make(stringChan),
-	}
+	s := newStore()
+	s.timeout = *mergeTimeout
+
+	b := newBroker(s.events)
 
 	go b.listen()
 
-	http.Handle("/events/", b)
+	box := packr.New("demo", "./web/public")
+	prefix := cleanBasePath(*basePath)
+
+	srv := &http.Server{
+		Addr:    fmt.Sprintf(":%d", *port),
+		Handler: mount(prefix, newHandler(s, b, http.FileServer(box))),
+	}
 
-	graphs := make([]string, 0)
+	// event streams never go idle on their own, end them so Shutdown can return
+	srv.RegisterOnShutdown(b.close)
 
-	http.HandleFunc("/data/", func(w http.ResponseWriter, r *http.Request) {
+	stopped := make(chan error, 1)
+
+	go func() {
+		sig := make(chan os.Signal, 1)
+		signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+		<-sig
+
+		log.Println("shutting down")
+
+		ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+
+		stopped <- srv.Shutdown(ctx)
+	}()
+
+	log.Printf("listening on port %s, serving under %s/", srv.Addr, prefix)
+
+	if err := srv.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	return <-stopped
+}
+
+func newHandler(s *store, b *broker, static http.Handler) http.Handler {
+	mux := http.NewServeMux()
+
+	mux.Handle("/events/", b)
+	mux.Handle("/data/", serveData(s, b))
+	mux.Handle("/progress/", serveProgress(b))
+	mux.Handle("/diff/", serveDiff(s.all))
+	mux.Handle("/", static)
+
+	return mux
+}
+
+func serveData(s *store, b *broker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
 		enableCors(&w)
 		w.Header().Set("Content-Type", "application/json; charset=utf-8")
 
 		if r.Method == "GET" {
-			jsonData := strings.Join(graphs, ",")
-			fmt.Fprintf(w, "[%s]", jsonData)
+			if err := json.NewEncoder(w).Encode(s.all()); err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+			}
 
 			return
 		}
@@ -58,30 +147,128 @@ func run() error {
 			return
 		}
 
-		bts, err := ioutil.ReadAll(r.Body)
+		defer func() {
+			_ = r.Body.Close()
+		}()
+
+		doc, err := report.Decode(r.Body)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("invalid run document: %v", err), http.StatusBadRequest)
+
+			return
+		}
+
+		if doc.ID != "" {
+			mergePart(w, s, b, doc)
+
+			return
+		}
+
+		e, err := s.event(s.add(doc))
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 
 			return
 		}
 
+		b.publish(e)
+	}
+}
+
+// mergePart adds the part to the run with the same id and publishes the run
+// again under the same number, the UI replaces the previous version of it.
+func mergePart(w http.ResponseWriter, s *store, b *broker, part report.Run) {
+	n, merged, err := s.merge(part)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("merge run %q: %v", part.ID, err), http.StatusConflict)
+
+		return
+	}
+
+	e, err := s.event(n)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+
+		return
+	}
+
+	b.publish(e)
+
+	if merged.Received == 1 && !merged.Complete {
+		time.AfterFunc(s.timeout, func() {
+			n, expired, ok := s.expire(part.ID)
+			if !ok {
+				return
+			}
+
+			log.Printf("run %q complete after %s with %d of %d parts", part.ID, s.timeout, expired.Received, expired.Parts)
+
+			e, err := s.event(n)
+			if err != nil {
+				log.Printf("publish run %q: %v", part.ID, err)
+
+				return
+			}
+
+			b.publish(e)
+		})
+	}
+}
+
+// serveProgress forwards the progress of runs going on to the UI, it is not stored.
+func serveProgress(b *broker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		enableCors(&w)
+
+		if r.Method != "POST" {
+			http.Error(w, "only POST method expected", http.StatusMethodNotAllowed)
+
+			return
+		}
+
 		defer func() {
 			_ = r.Body.Close()
 		}()
 
-		graph := string(bts)
-		graphs = append([]string{graph}, graphs...)
-		b.messages <- graph
-	})
+		p, err := report.DecodeProgress(r.Body)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("invalid progress event: %v", err), http.StatusBadRequest)
 
-	box := packr.New("demo", "./web/public")
-	dir := http.FileServer(box)
-	http.Handle("/", dir)
+			return
+		}
+
+		bts, err := json.Marshal(p)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+
+			return
+		}
+
+		b.publish(event{name: "progress", data: string(bts)})
+	}
+}
+
+// cleanBasePath turns the flag value into either an empty string or a path
+// with a leading and no trailing slash.
+func cleanBasePath(p string) string {
+	p = strings.Trim(p, "/")
+	if p == "" {
+		return ""
+	}
+
+	return "/" + p
+}
+
+// mount serves h under the given prefix, requests outside of it are not found.
+func mount(prefix string, h http.Handler) http.Handler {
+	if prefix == "" {
+		return h
+	}
 
-	portStr := fmt.Sprintf(":%d", *port)
-	log.Printf("listening on port %s", portStr)
+	root := http.NewServeMux()
+	root.Handle(prefix+"/", http.StripPrefix(prefix, h))
 
-	return http.ListenAndServe(portStr, nil)
+	return root
 }
 
 func enableCors(w *http.ResponseWriter) {
@@ -0,0 +1,383 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/anatollupacescu/arbortest/report"
+)
+
+const validRun = `{"version":2,"commit":"abc","nodes":[{"id":"group","kind":"group","status":"pass"},` +
+	`{"id":"group/test","label":"test","kind":"test","group":"group","status":"pass"}],` +
+	`"links":[{"source":"group/test","target":"group","value":1}]}`
+
+func newTestServer(t *testing.T) (*httptest.Server, *store) {
+	s := newStore()
+	b := newBroker(s.events)
+
+	go b.listen()
+
+	srv := httptest.NewServer(newHandler(s, b, http.NotFoundHandler()))
+
+	t.Cleanup(func() {
+		b.close()
+		srv.Close()
+	})
+
+	return srv, s
+}
+
+func post(t *testing.T, url, body string) *http.Response {
+	resp, err := http.Post(url, "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		_ = resp.Body.Close()
+	})
+
+	return resp
+}
+
+func TestServerFlagsLeaveOutTheTestFlags(t *testing.T) {
+	serverFlags.VisitAll(func(f *flag.Flag) {
+		assert.False(t, strings.HasPrefix(f.Name, "arbor"), f.Name)
+	})
+
+	assert.NotNil(t, serverFlags.Lookup("port"))
+}
+
+func TestPostValidRun(t *testing.T) {
+	srv, s := newTestServer(t)
+
+	resp := post(t, srv.URL+"/data/", validRun)
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Len(t, s.all(), 1)
+	assert.Equal(t, report.Version, s.all()[0].Version)
+}
+
+func TestPostInvalidRun(t *testing.T) {
+	srv, s := newTestServer(t)
+
+	resp := post(t, srv.URL+"/data/", `{"version":2,"nodes":[{"id":"a","kind":"group","status":"green"}]}`)
+
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Empty(t, s.all())
+}
+
+func TestDataMethodNotAllowed(t *testing.T) {
+	srv, _ := newTestServer(t)
+
+	req, _ := http.NewRequest(http.MethodDelete, srv.URL+"/data/", nil)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+}
+
+func TestGetReturnsNewestFirst(t *testing.T) {
+	srv, _ := newTestServer(t)
+
+	post(t, srv.URL+"/data/", validRun)
+	post(t, srv.URL+"/data/", strings.Replace(validRun, "abc", "def", 1))
+
+	resp, err := http.Get(srv.URL + "/data/")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer resp.Body.Close()
+
+	var runs []report.Run
+
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&runs))
+	assert.Len(t, runs, 2)
+	assert.Equal(t, "def", runs[0].Commit)
+	assert.Equal(t, "abc", runs[1].Commit)
+}
+
+func TestConcurrentPostAndGet(t *testing.T) {
+	srv, s := newTestServer(t)
+
+	var wg sync.WaitGroup
+
+	const uploads = 10
+
+	for i := 0; i < uploads; i++ {
+		wg.Add(2)
+
+		go func() {
+			defer wg.Done()
+
+			resp, err := http.Post(srv.URL+"/data/", "application/json", strings.NewReader(validRun))
+			if err == nil {
+				_ = resp.Body.Close()
+			}
+		}()
+
+		go func() {
+			defer wg.Done()
+
+			resp, err := http.Get(srv.URL + "/data/")
+			if err == nil {
+				_ = resp.Body.Close()
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	assert.Len(t, s.all(), uploads)
+}
+
+func TestDiffEndpoint(t *testing.T) {
+	srv, _ := newTestServer(t)
+
+	post(t, srv.URL+"/data/", validRun)
+	post(t, srv.URL+"/data/", strings.Replace(validRun, `"group":"group","status":"pass"`, `"group":"group","status":"fail"`, 1))
+
+	resp, err := http.Get(srv.URL + "/diff/")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer resp.Body.Close()
+
+	var c comparison
+
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&c))
+	assert.Equal(t, 1, c.Base)
+	assert.Equal(t, 2, c.Head)
+	assert.Equal(t, []report.Transition{{ID: "group/test", From: "pass", To: "fail"}}, c.Transitions)
+}
+
+func TestDiffWithoutPreviousRun(t *testing.T) {
+	srv, _ := newTestServer(t)
+
+	post(t, srv.URL+"/data/", validRun)
+
+	resp, err := http.Get(srv.URL + "/diff/?base=unknown")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestProgressIsStreamedButNotStored(t *testing.T) {
+	srv, s := newTestServer(t)
+
+	// headers are flushed once the client is subscribed
+	resp, err := http.Get(srv.URL + "/events/")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	progress := `{"run":"r1","event":"update","nodes":[{"id":"group","kind":"group","status":"running"}]}`
+
+	assert.Equal(t, http.StatusOK, post(t, srv.URL+"/progress/", progress).StatusCode)
+
+	lines := bufio.NewScanner(resp.Body)
+
+	var received []string
+
+	for lines.Scan() && lines.Text() != "" {
+		received = append(received, lines.Text())
+	}
+
+	expected := []string{
+		"event: progress",
+		`data: {"run":"r1","event":"update","nodes":[{"id":"group","label":"","kind":"group","status":"running"}]}`,
+	}
+
+	assert.Equal(t, expected, received)
+	assert.Empty(t, s.all())
+}
+
+// readEvents reads n events of the stream, skipping comments.
+func readEvents(t *testing.T, lines *bufio.Scanner, n int) [][]string {
+	t.Helper()
+
+	var (
+		events  [][]string
+		current []string
+	)
+
+	for len(events) < n && lines.Scan() {
+		switch l := lines.Text(); {
+		case l == "" && current != nil:
+			events = append(events, current)
+			current = nil
+		case l != "" && !strings.HasPrefix(l, ":"):
+			current = append(current, l)
+		}
+	}
+
+	if len(events) < n {
+		t.Fatalf("expected %d events, got %v", n, events)
+	}
+
+	return events
+}
+
+func subscribe(t *testing.T, url, lastID string) *bufio.Scanner {
+	t.Helper()
+
+	req, _ := http.NewRequest(http.MethodGet, url+"/events/", nil)
+	if lastID != "" {
+		req.Header.Set("Last-Event-ID", lastID)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		_ = resp.Body.Close()
+	})
+
+	return bufio.NewScanner(resp.Body)
+}
+
+func TestMergedPartReachesReconnectedClient(t *testing.T) {
+	srv, _ := newTestServer(t)
+
+	part := func(group string) string {
+		return `{"version":2,"id":"ci-42","parts":2,"nodes":[{"id":"` + group + `","kind":"group","status":"pass"}]}`
+	}
+
+	post(t, srv.URL+"/data/", part("a"))
+	post(t, srv.URL+"/data/", validRun)
+
+	// the client saw both runs before losing the connection
+	stream := subscribe(t, srv.URL, "2")
+
+	post(t, srv.URL+"/data/", part("b"))
+
+	e := readEvents(t, stream, 1)[0]
+	assert.Equal(t, "id: 3", e[0])
+	assert.Contains(t, e[1], `"number":1`)
+	assert.Contains(t, e[1], `"complete":true`)
+
+	// a client away while the part was merged gets it when catching up
+	events := readEvents(t, subscribe(t, srv.URL, "1"), 2)
+	assert.Equal(t, "id: 2", events[0][0])
+	assert.Contains(t, events[0][1], `"number":2`)
+	assert.Equal(t, "id: 3", events[1][0])
+	assert.Contains(t, events[1][1], `"number":1`)
+}
+
+func TestPostInvalidProgress(t *testing.T) {
+	srv, _ := newTestServer(t)
+
+	resp := post(t, srv.URL+"/progress/", `{"run":"r1","event":"pause"}`)
+
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestPartsAreMergedIntoOneRun(t *testing.T) {
+	srv, s := newTestServer(t)
+
+	part := func(group, status string) string {
+		return `{"version":2,"commit":"abc","id":"ci-42","parts":2,"nodes":[{"id":"` + group +
+			`","kind":"group","status":"` + status + `"}]}`
+	}
+
+	assert.Equal(t, http.StatusOK, post(t, srv.URL+"/data/", part("a", "pass")).StatusCode)
+
+	runs := s.all()
+	assert.Len(t, runs, 1)
+	assert.False(t, runs[0].Complete)
+
+	post(t, srv.URL+"/data/", validRun)
+	assert.Equal(t, http.StatusOK, post(t, srv.URL+"/data/", part("b", "fail")).StatusCode)
+
+	runs = s.all()
+	assert.Len(t, runs, 2, "the parts make a single run")
+	assert.Equal(t, "ci-42", runs[1].ID)
+	assert.Len(t, runs[1].Nodes, 2)
+	assert.Equal(t, 2, runs[1].Received)
+	assert.True(t, runs[1].Complete)
+
+	resp := post(t, srv.URL+"/data/", part("c", "pass"))
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+}
+
+func TestConflictingPartIsRejected(t *testing.T) {
+	srv, s := newTestServer(t)
+
+	post(t, srv.URL+"/data/", `{"version":2,"id":"ci-42","nodes":[{"id":"a","kind":"group","status":"pass"}]}`)
+
+	resp := post(t, srv.URL+"/data/", `{"version":2,"id":"ci-42","nodes":[{"id":"a","kind":"group","status":"fail"}]}`)
+
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+	assert.Equal(t, 1, s.all()[0].Received)
+}
+
+func TestPartUploadedAgainIsReplaced(t *testing.T) {
+	s := newStore()
+
+	part := func(name, status string) report.Run {
+		return report.Run{Version: report.Version, ID: "ci-42", Parts: 2, Part: name,
+			Nodes: []report.Node{{ID: name, Kind: report.KindGroup, Status: status}}}
+	}
+
+	_, _, err := s.merge(part("a", report.StatusFail))
+	assert.NoError(t, err)
+
+	_, run, err := s.merge(part("a", report.StatusPass))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, run.Received, "the retried part replaces the first upload")
+	assert.Equal(t, []report.Node{{ID: "a", Kind: report.KindGroup, Status: report.StatusPass}}, run.Nodes)
+	assert.False(t, run.Complete)
+
+	_, run, err = s.merge(part("b", report.StatusPass))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, run.Received)
+	assert.True(t, run.Complete)
+	assert.Empty(t, s.assemblies, "complete runs are not kept as assemblies")
+
+	_, _, err = s.merge(part("b", report.StatusPass))
+	assert.True(t, errors.Is(err, errComplete))
+}
+
+func TestExpiredRunIsComplete(t *testing.T) {
+	s := newStore()
+
+	_, _, err := s.merge(report.Run{Version: report.Version, ID: "ci-42", Parts: 3,
+		Nodes: []report.Node{{ID: "a", Kind: report.KindGroup, Status: report.StatusPass}}})
+	assert.NoError(t, err)
+
+	n, run, ok := s.expire("ci-42")
+	assert.True(t, ok)
+	assert.Equal(t, 1, n)
+	assert.True(t, run.Complete)
+	assert.True(t, s.all()[0].Complete)
+
+	_, _, ok = s.expire("ci-42")
+	assert.False(t, ok, "expires once")
+	assert.Empty(t, s.assemblies)
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/anatollupacescu/arbortest/report"
+)
+
+var errComplete = errors.New("run already complete")
+
+// store keeps the uploaded runs in memory, it is safe for concurrent use.
+// Runs are numbered from 1, starting with the oldest one.
+type store struct {
+	mu   sync.RWMutex
+	runs []report.Run
+	// rev counts the changes to the runs, revs holds the one each run was
+	// last changed in, runs uploaded in parts change after they are added.
+	rev  int
+	revs []int
+	// assemblies holds the parts received so far of runs uploaded in parts, by
+	// run id, timeout is how long to wait for all of them.
+	assemblies map[string]*assembly
+	timeout    time.Duration
+}
+
+// assembly is a run uploaded in parts, stored under number n, until all of
+// them are received or the server gives up waiting.
+type assembly struct {
+	n     int
+	parts []report.Run
+}
+
+// with returns the parts along with the given one, which replaces the part
+// with the same id if it was received before.
+func (a assembly) with(part report.Run) []report.Run {
+	parts := make([]report.Run, 0, len(a.parts)+1)
+
+	for _, p := range a.parts {
+		if id := p.PartID(); id == "" || id != part.PartID() {
+			parts = append(parts, p)
+		}
+	}
+
+	return append(parts, part)
+}
+
+func newStore() *store {
+	return &store{
+		runs:       make([]report.Run, 0),
+		revs:       make([]int, 0),
+		assemblies: make(map[string]*assembly),
+		timeout:    defaultMergeTimeout,
+	}
+}
+
+// merge adds a part to the run with the same id, storing the run when it is
+// the first part, and returns the run number along with the merged run.
+func (s *store) merge(part report.Run) (int, report.Run, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	a, ok := s.assemblies[part.ID]
+	if !ok {
+		if s.completed(part.ID) {
+			return 0, report.Run{}, fmt.Errorf("%w: %q", errComplete, part.ID)
+		}
+
+		a = &assembly{n: len(s.runs) + 1}
+	}
+
+	parts := a.with(part)
+
+	merged, err := report.Merge(parts...)
+	if err != nil {
+		return 0, report.Run{}, err
+	}
+
+	a.parts = parts
+
+	if !ok {
+		s.assemblies[part.ID] = a
+		s.runs = append(s.runs, merged)
+		s.revs = append(s.revs, 0)
+	}
+
+	s.runs[a.n-1] = merged
+	s.changed(a.n)
+
+	if merged.Complete {
+		delete(s.assemblies, part.ID)
+	}
+
+	return a.n, merged, nil
+}
+
+// completed tells whether a run with the id was already put together.
+func (s *store) completed(id string) bool {
+	for _, r := range s.runs {
+		if r.ID == id && r.Complete {
+			return true
+		}
+	}
+
+	return false
+}
+
+// expire marks the run complete without waiting for its missing parts,
+// it tells whether the run was still waiting.
+func (s *store) expire(id string) (int, report.Run, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	a, ok := s.assemblies[id]
+	if !ok {
+		return 0, report.Run{}, false
+	}
+
+	delete(s.assemblies, id)
+
+	s.runs[a.n-1].Complete = true
+	s.changed(a.n)
+
+	return a.n, s.runs[a.n-1], true
+}
+
+// add saves the run and returns its number.
+func (s *store) add(r report.Run) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.runs = append(s.runs, r)
+	s.revs = append(s.revs, 0)
+	s.changed(len(s.runs))
+
+	return len(s.runs)
+}
+
+// changed records that run n changed in a new revision.
+func (s *store) changed(n int) {
+	s.rev++
+	s.revs[n-1] = s.rev
+}
+
+// all returns a snapshot of the stored runs, newest first.
+func (s *store) all() []report.Run {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	runs := make([]report.Run, 0, len(s.runs))
+	for i := len(s.runs) - 1; i >= 0; i-- {
+		runs = append(runs, s.runs[i])
+	}
+
+	return runs
+}
+
+// numbered is a run along with its number, the way it is streamed to the UI.
+type numbered struct {
+	Number int `json:"number"`
+	report.Run
+}
+
+// event returns the latest version of run n, the id of the event is the
+// revision the run last changed in.
+func (s *store) event(n int) (event, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.eventOf(n)
+}
+
+func (s *store) eventOf(n int) (event, error) {
+	bts, err := json.Marshal(numbered{Number: n, Run: s.runs[n-1]})
+	if err != nil {
+		return event{}, err
+	}
+
+	return event{id: s.revs[n-1], data: string(bts)}, nil
+}
+
+// events converts the runs which changed after revision lastID to events,
+// in the order they changed, so that a client catching up also gets the runs
+// merged since.
+func (s *store) events(lastID int) []event {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	events := make([]event, 0)
+
+	for n := 1; n <= len(s.runs); n++ {
+		if s.revs[n-1] <= lastID {
+			continue
+		}
+
+		e, err := s.eventOf(n)
+		if err != nil {
+			log.Printf("replay run %d: %v", n, err)
+			continue
+		}
+
+		events = append(events, e)
+	}
+
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].id < events[j].id
+	})
+
+	return events
+}
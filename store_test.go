@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/anatollupacescu/arbortest/report"
+)
+
+func TestStoreNumbersRunsFromOldest(t *testing.T) {
+	s := newStore()
+
+	assert.Equal(t, 1, s.add(report.Run{Commit: "first"}))
+	assert.Equal(t, 2, s.add(report.Run{Commit: "second"}))
+
+	runs := s.all()
+	assert.Len(t, runs, 2)
+	assert.Equal(t, "second", runs[0].Commit)
+	assert.Equal(t, "first", runs[1].Commit)
+}
+
+func TestStoreEventsAfterLastID(t *testing.T) {
+	s := newStore()
+	s.add(report.Run{Commit: "first"})
+	s.add(report.Run{Commit: "second"})
+	s.add(report.Run{Commit: "third"})
+
+	events := s.events(1)
+	assert.Len(t, events, 2)
+	assert.Equal(t, 2, events[0].id)
+	assert.Contains(t, events[0].data, `"commit":"second"`)
+	assert.Equal(t, 3, events[1].id)
+
+	assert.Empty(t, s.events(3))
+}
+
+func TestStoreConcurrentAccess(t *testing.T) {
+	s := newStore()
+
+	var wg sync.WaitGroup
+
+	const writers = 20
+
+	for i := 0; i < writers; i++ {
+		wg.Add(2)
+
+		go func(i int) {
+			defer wg.Done()
+			s.add(report.Run{Commit: fmt.Sprint(i)})
+		}(i)
+
+		go func() {
+			defer wg.Done()
+			_ = s.all()
+			_ = s.events(0)
+		}()
+	}
+
+	wg.Wait()
+
+	assert.Len(t, s.all(), writers)
+}
@@ -24,6 +24,21 @@ var app = (function () {
     function safe_not_equal(a, b) {
         return a != a ? b == b : a !== b || ((a && typeof a === 'object') || typeof a === 'function');
     }
+    function validate_store(store, name) {
+        if (store != null && typeof store.subscribe !== 'function') {
+            throw new Error(`'${name}' is not a store with a 'subscribe' method`);
+        }
+    }
+    function subscribe(store, ...callbacks) {
+        if (store == null) {
+            return noop;
+        }
+        const unsub = store.subscribe(...callbacks);
+        return unsub.unsubscribe ? () => unsub.unsubscribe() : unsub;
+    }
+    function component_subscribe(component, store, callback) {
+        component.$$.on_destroy.push(subscribe(store, callback));
+    }
 
     function append(target, node) {
         target.appendChild(node);
@@ -56,6 +71,13 @@ var app = (function () {
         node.addEventListener(event, handler, options);
         return () => node.removeEventListener(event, handler, options);
     }
+    function stop_propagation(fn) {
+        return function (event) {
+            event.stopPropagation();
+            // @ts-ignore
+            return fn.call(this, event);
+        };
+    }
     function attr(node, attribute, value) {
         if (value == null)
             node.removeAttribute(attribute);
@@ -4536,19 +4558,21 @@ var app = (function () {
 
     let cats = [
       {
+        number: 2,
         commit: "df423i",
         message: "some new feature",
         nodes: [
-          { id: "group", group: 2, status: "pass" },
-          { id: "test", group: 2, status: "pass" },
+          { id: "group", label: "group", kind: "group", status: "pass" },
+          { id: "group/test", label: "test", kind: "test", group: "group", status: "pass" },
         ],
-        links: [{ source: "test", target: "group", value: 3 }],
+        links: [{ source: "group/test", target: "group", value: 1 }],
       },  {
+        number: 1,
         commit: "eabb33",
         message: "a fix for an old bug",
         nodes: [
-          { id: "second", group: 2, status: "pass" },
-          { id: "pair", group: 2, status: "pass" },
+          { id: "second", label: "second", kind: "group", status: "pass" },
+          { id: "pair", label: "pair", kind: "group", status: "pass" },
         ],
         links: [{ source: "second", target: "pair", value: 3 }],
       },
@@ -4558,6 +4582,14 @@ var app = (function () {
 
     const current = writable({});
 
+    // runs are numbered from 1 starting with the oldest, 0 selects the latest run
+    const selected = writable(0);
+
+    // run the selected one is compared with, 0 selects the run preceding it
+    const baseline = writable(0);
+
+    const compare = writable(false);
+
     function forceCenter(x, y) {
       var nodes;
 
@@ -5395,7 +5427,7 @@ var app = (function () {
     		c: function create() {
     			div = element("div");
     			attr_dev(div, "class", "chartdiv");
-    			add_location(div, file, 198, 0, 4782);
+    			add_location(div, file, 351, 0, 9269);
     		},
     		l: function claim(nodes) {
     			throw new Error("options.hydrate only works if the component was compiled with the `hydratable: true` option");
@@ -5423,6 +5455,16 @@ var app = (function () {
     }
 
     function instance($$self, $$props, $$invalidate) {
+    	let $compare;
+    	let $selected;
+    	let $baseline;
+    	validate_store(compare, "compare");
+    	component_subscribe($$self, compare, $$value => $$invalidate(0, $compare = $$value));
+    	validate_store(selected, "selected");
+    	component_subscribe($$self, selected, $$value => $$invalidate(1, $selected = $$value));
+    	validate_store(baseline, "baseline");
+    	component_subscribe($$self, baseline, $$value => $$invalidate(2, $baseline = $$value));
+
     	let d3 = {
     		zoom,
     		zoomIdentity: identity$3,
@@ -5441,23 +5483,98 @@ var app = (function () {
     	const colors = {
     		"skip": "grey",
     		"fail": "red",
-    		"pass": "green"
+    		"pass": "green",
+    		"skipped": "lightgrey",
+    		"not-run": "whitesmoke",
+    		"pending": "white",
+    		"running": "gold"
+    	};
+
+    	// ring colors for nodes which changed compared to the baseline run
+    	const changes = {
+    		"added": "#4679BD",
+    		"broken": "#d62728",
+    		"fixed": "#2ca02c",
+    		"skipped": "#7f7f7f",
+    		"slower": "#ff7f0e"
     	};
 
     	let graph;
-    	let simulation, svg;
+    	let changed = {};
+
+    	// nodes leading from the clicked skipped node to the failed test that caused it
+    	let cause = {};
+
+    	let simulation, svg, view;
     	let width, height;
-    	let source = new EventSource("http://localhost:3000/events/");
+
+    	// endpoints are relative to the page, so the UI keeps working behind a
+    	// reverse proxy or when the server is started with -base-path
+    	let source = new EventSource("events/");
 
     	source.onmessage = function (e) {
+    		// the server numbers the runs, runs uploaded in parts come with the same number
     		graph = JSON.parse(e.data);
-    		store.update(graphs => [graph, ...graphs]);
-    		d3.selectAll("svg > *").remove();
-    		let links = graph.links.map(d => Object.create(d));
-    		let nodes = graph.nodes.map(d => Object.create(d));
-    		renderGraph(nodes, links);
+
+    		selected.set(0);
+
+    		store.update(graphs => {
+    			// runs uploaded in parts come again, under the same number, each time a part is merged
+    			let previous = graphs.findIndex(g => g.number === graph.number);
+
+    			if (previous >= 0) {
+    				graphs[previous] = graph;
+    				return graphs;
+    			}
+
+    			if (graphs.length >= 10) {
+    				graphs.pop();
+    			}
+
+    			return [graph, ...graphs];
+    		});
+
+    		refreshGraph(graph);
     	};
 
+    	// the run going on, streamed by runners started with -arbor.live
+    	let live = null;
+
+    	source.addEventListener("progress", function (e) {
+    		let p = JSON.parse(e.data);
+
+    		if (p.event === "start" || live === null || live.run !== p.run) {
+    			live = { run: p.run, nodes: {}, links: {} };
+    		}
+
+    		let grown = false;
+
+    		(p.nodes || []).forEach(n => {
+    			grown = grown || !(n.id in live.nodes);
+    			live.nodes[n.id] = n;
+    		});
+
+    		(p.links || []).forEach(l => {
+    			let key = `${l.source} ${l.target}`;
+    			grown = grown || !(key in live.links);
+    			live.links[key] = l;
+    		});
+
+    		if (grown) {
+    			// new nodes need a new layout, status changes are only repainted
+    			refreshGraph({
+    				nodes: Object.values(live.nodes),
+    				links: Object.values(live.links)
+    			});
+    		} else {
+    			d3.selectAll("circle").attr("fill", d => colors[(live.nodes[d.id] || d).status]);
+    		}
+
+    		if (p.event === "end") {
+    			live = null;
+    		}
+    	});
+
     	current.subscribe(graph => {
     		if (graph.links === undefined) {
     			return;
@@ -5466,7 +5583,75 @@ var app = (function () {
     		refreshGraph(graph);
     	});
 
+    	function loadDiff(enabled, head, base) {
+    		if (!enabled) {
+    			highlight({});
+    			return;
+    		}
+
+    		let params = new URLSearchParams();
+    		if (head) params.set("head", head);
+    		if (base) params.set("base", base);
+    		fetch("diff/?" + params).then(d => d.ok ? d.json() : {}).then(highlight);
+    	}
+
+    	function highlight(diff) {
+    		changed = {};
+    		(diff.added || []).forEach(id => changed[id] = "added");
+    		(diff.regressions || []).forEach(r => changed[r.id] = "slower");
+
+    		(diff.transitions || []).forEach(t => {
+    			if (t.to === "fail") changed[t.id] = "broken";
+    			if (t.to === "pass") changed[t.id] = "fixed";
+    		});
+
+    		(diff.skipped || []).forEach(id => changed[id] = "skipped");
+    		paintChanges();
+    	}
+
+    	function paintChanges() {
+    		d3.selectAll("circle").attr("stroke", ringColor).attr("stroke-width", ringWidth).attr("stroke-dasharray", ringDashes);
+    	}
+
+    	function ringColor(d) {
+    		if (cause[d.id] === "origin") return "black";
+    		if (cause[d.id] === "chain") return "#555";
+    		return changes[changed[d.id]] || null;
+    	}
+
+    	function ringWidth(d) {
+    		return cause[d.id] || changed[d.id] ? 5 : null;
+    	}
+
+    	function ringDashes(d) {
+    		return cause[d.id] === "chain" ? "4,2" : null;
+    	}
+
+    	// jumps from a skipped node to the failed test its skip originates from
+    	function showCause(d) {
+    		cause = {};
+    		let chain = d.skippedBecause || [];
+
+    		if (chain.length === 0) {
+    			view.attr("transform", null);
+    			paintChanges();
+    			return;
+    		}
+
+    		let origin = chain[chain.length - 1];
+    		chain.forEach(id => cause[id] = "chain");
+    		cause[origin] = "origin";
+    		paintChanges();
+    		let target = d3.selectAll("circle").filter(n => n.id === origin);
+
+    		if (!target.empty()) {
+    			let o = target.datum();
+    			view.attr("transform", `translate(${width / 2 - o.x},${height / 2 - o.y})`);
+    		}
+    	}
+
     	function refreshGraph(graph) {
+    		cause = {};
     		d3.selectAll("svg > *").remove();
     		let links = graph.links.map(d => Object.create(d));
     		let nodes = graph.nodes.map(d => Object.create(d));
@@ -5476,7 +5661,9 @@ var app = (function () {
     	onMount(() => {
     		height = width = window.innerHeight;
     		svg = d3.select(".chart").append("svg").attr("width", width).attr("height", height);
-    		fetch("http://localhost:3000/data/").then(d => d.json()).then(d => store.update(() => d));
+
+    		// all the runs, newest first, numbered from 1 starting with the oldest
+    		fetch("data/").then(d => d.json()).then(d => store.update(() => d.map((run, i) => Object.assign(run, { number: d.length - i }))));
     	});
 
     	function renderGraph(nodes, links) {
@@ -5486,18 +5673,26 @@ var app = (function () {
     			return lengthUnit * n.value;
     		}).strength(1)).force("charge", d3.forceManyBody()).force("center", d3.forceCenter(width / 2, height / 2)).on("tick", simulationUpdate);
 
-    		const g = svg.append("g");
+    		view = svg.append("g");
+    		const g = view.append("g");
 
     		svg.append("defs").selectAll("marker").data(["suit", "licensing", "resolved"]).enter().append("marker").attr("id", function (d) {
     			return d;
     		}).attr("viewBox", "0 -5 10 10").attr("refX", 25).attr("refY", 0).attr("markerWidth", 6).attr("markerHeight", 6).attr("orient", "auto").append("path").attr("d", "M0,-5L10,0L0,5 L10,0 L0, -5").style("stroke", "#4679BD").style("opacity", "0.6");
 
     		const link = g.append("g").attr("stroke", "#999").attr("stroke-opacity", 0.6).selectAll("line").data(links).join("line").attr("stroke-width", d => Math.sqrt(d.value)).style("marker-end", "url(#suit)");
-    		const node = g.append("g").attr("stroke", "#fff").attr("stroke-width", 1.5).selectAll("circle").data(nodes).join("circle").attr("r", 15).attr("fill", d => colors[d.status]).call(d3.drag().on("start", dragstarted).on("drag", dragged).on("end", dragended));
-    		var gnode = svg.append("g").attr("class", "nodes").selectAll("g").data(nodes).enter().append("g");
+    		const node = g.append("g").attr("stroke", "#fff").attr("stroke-width", 1.5).selectAll("circle").data(nodes).join("circle").attr("r", 15).attr("fill", d => colors[d.status]).attr("fill-opacity", d => d.carriedOver ? 0.5 : null).attr("stroke", ringColor).attr("stroke-width", ringWidth).attr("stroke-dasharray", ringDashes).style("cursor", d => d.skippedBecause ? "pointer" : null).on("click", showCause).call(d3.drag().on("start", dragstarted).on("drag", dragged).on("end", dragended));
+
+    		node.append("title").text(d => d.skippedBecause
+    		? `skipped because '${d.skippedBecause[d.skippedBecause.length - 1]}' failed`
+    		: d.carriedOver
+    			? `${d.status}, carried over from the previous run`
+    			: d.status);
+
+    		var gnode = view.append("g").attr("class", "nodes").selectAll("g").data(nodes).enter().append("g");
 
     		gnode.append("text").text(function (d) {
-    			return d.id;
+    			return d.label || d.id;
     		});
 
     		function simulationUpdate() {
@@ -5550,6 +5745,9 @@ var app = (function () {
     		drag,
     		store,
     		current,
+    		selected,
+    		baseline,
+    		compare,
     		forceSimulation,
     		forceLink,
     		forceManyBody,
@@ -5557,34 +5755,59 @@ var app = (function () {
     		currentEvent: event,
     		d3,
     		colors,
+    		changes,
     		graph,
+    		changed,
+    		cause,
     		simulation,
     		svg,
+    		view,
     		width,
     		height,
     		source,
+    		live,
+    		loadDiff,
+    		highlight,
+    		paintChanges,
+    		ringColor,
+    		ringWidth,
+    		ringDashes,
+    		showCause,
     		refreshGraph,
     		renderGraph,
     		dragstarted,
     		dragged,
-    		dragended
+    		dragended,
+    		$compare,
+    		$selected,
+    		$baseline
     	});
 
     	$$self.$inject_state = $$props => {
     		if ("d3" in $$props) d3 = $$props.d3;
     		if ("graph" in $$props) graph = $$props.graph;
+    		if ("changed" in $$props) changed = $$props.changed;
+    		if ("cause" in $$props) cause = $$props.cause;
     		if ("simulation" in $$props) simulation = $$props.simulation;
     		if ("svg" in $$props) svg = $$props.svg;
+    		if ("view" in $$props) view = $$props.view;
     		if ("width" in $$props) width = $$props.width;
     		if ("height" in $$props) height = $$props.height;
     		if ("source" in $$props) source = $$props.source;
+    		if ("live" in $$props) live = $$props.live;
     	};
 
     	if ($$props && "$$inject" in $$props) {
     		$$self.$inject_state($$props.$$inject);
     	}
 
-    	return [];
+    	$$self.$$.update = () => {
+    		if ($$self.$$.dirty & /*$compare, $selected, $baseline*/ 7) {
+    			 loadDiff($compare, $selected, $baseline);
+    		}
+    	};
+
+    	return [$compare, $selected, $baseline];
     }
 
     class Graph extends SvelteComponentDev {
@@ -5606,32 +5829,106 @@ var app = (function () {
 
     function get_each_context(ctx, list, i) {
     	const child_ctx = ctx.slice();
-    	child_ctx[4] = list[i].commit;
-    	child_ctx[5] = list[i].message;
-    	child_ctx[7] = i;
+    	child_ctx[8] = list[i].number;
+    	child_ctx[9] = list[i].commit;
+    	child_ctx[10] = list[i].message;
+    	child_ctx[11] = list[i].id;
+    	child_ctx[12] = list[i].parts;
+    	child_ctx[13] = list[i].received;
+    	child_ctx[14] = list[i].complete;
+    	child_ctx[16] = i;
     	return child_ctx;
     }
 
-    // (17:0) {#each records as { commit, message }
+    // (40:4) {#if id}
+    function create_if_block(ctx) {
+    	let div;
+    	let t0_value = /*id*/ ctx[11] + "";
+    	let t0;
+    	let t1;
+
+    	let t2_value = (/*parts*/ ctx[12]
+    	? `${/*received*/ ctx[13]} of ${/*parts*/ ctx[12]}`
+    	: /*received*/ ctx[13]) + "";
+
+    	let t2;
+    	let t3;
+    	let t4_value = (/*complete*/ ctx[14] ? "" : ", waiting for more") + "";
+    	let t4;
+
+    	const block = {
+    		c: function create() {
+    			div = element("div");
+    			t0 = text(t0_value);
+    			t1 = text(": ");
+    			t2 = text(t2_value);
+    			t3 = text(" parts");
+    			t4 = text(t4_value);
+    			attr_dev(div, "class", "text-muted");
+    			add_location(div, file$1, 40, 4, 1202);
+    		},
+    		m: function mount(target, anchor) {
+    			insert_dev(target, div, anchor);
+    			append_dev(div, t0);
+    			append_dev(div, t1);
+    			append_dev(div, t2);
+    			append_dev(div, t3);
+    			append_dev(div, t4);
+    		},
+    		p: function update(ctx, dirty) {
+    			if (dirty & /*records*/ 1 && t0_value !== (t0_value = /*id*/ ctx[11] + "")) set_data_dev(t0, t0_value);
+
+    			if (dirty & /*records*/ 1 && t2_value !== (t2_value = (/*parts*/ ctx[12]
+    			? `${/*received*/ ctx[13]} of ${/*parts*/ ctx[12]}`
+    			: /*received*/ ctx[13]) + "")) set_data_dev(t2, t2_value);
+
+    			if (dirty & /*records*/ 1 && t4_value !== (t4_value = (/*complete*/ ctx[14] ? "" : ", waiting for more") + "")) set_data_dev(t4, t4_value);
+    		},
+    		d: function destroy(detaching) {
+    			if (detaching) detach_dev(div);
+    		}
+    	};
+
+    	dispatch_dev("SvelteRegisterBlock", {
+    		block,
+    		id: create_if_block.name,
+    		type: "if",
+    		source: "(40:4) {#if id}",
+    		ctx
+    	});
+
+    	return block;
+    }
+
+    // (28:0) {#each records as { number, commit, message, id, parts, received, complete }
     function create_each_block(ctx) {
     	let div2;
     	let div0;
     	let h6;
-    	let t0_value = /*len*/ ctx[1] - /*i*/ ctx[7] + "";
+    	let t0_value = /*number*/ ctx[8] + "";
     	let t0;
     	let t1;
-    	let t2_value = /*commit*/ ctx[4] + "";
+    	let t2_value = /*commit*/ ctx[9] + "";
     	let t2;
     	let t3;
-    	let div1;
-    	let t4_value = /*message*/ ctx[5] + "";
+    	let i_1;
     	let t4;
+    	let div1;
+    	let t5_value = /*message*/ ctx[10] + "";
     	let t5;
+    	let t6;
+    	let t7;
     	let mounted;
     	let dispose;
 
+    	function click_handler_1(...args) {
+    		return /*click_handler_1*/ ctx[7](/*i*/ ctx[16], ...args);
+    	}
+
+    	let if_block = /*id*/ ctx[11] && create_if_block(ctx);
+
     	function click_handler(...args) {
-    		return /*click_handler*/ ctx[3](/*i*/ ctx[7], ...args);
+    		return /*click_handler*/ ctx[6](/*i*/ ctx[16], ...args);
     	}
 
     	const block = {
@@ -5643,18 +5940,26 @@ var app = (function () {
     			t1 = text(": ");
     			t2 = text(t2_value);
     			t3 = space();
+    			i_1 = element("i");
+    			t4 = space();
     			div1 = element("div");
-    			t4 = text(t4_value);
-    			t5 = space();
+    			t5 = text(t5_value);
+    			t6 = space();
+    			if (if_block) if_block.c();
+    			t7 = space();
+    			attr_dev(i_1, "class", "fa fa-thumb-tack float-right");
+    			attr_dev(i_1, "title", "use as baseline");
+    			set_style(i_1, "opacity", /*$baseline*/ ctx[1] === /*number*/ ctx[8] ? 1 : 0.3);
+    			add_location(i_1, file$1, 32, 6, 939);
     			attr_dev(h6, "class", "my-0 font-weight-normal");
-    			add_location(h6, file$1, 19, 4, 412);
+    			add_location(h6, file$1, 30, 4, 871);
     			attr_dev(div0, "class", "card-header");
-    			add_location(div0, file$1, 18, 2, 382);
+    			add_location(div0, file$1, 29, 2, 841);
     			attr_dev(div1, "class", "card-body");
-    			add_location(div1, file$1, 21, 2, 484);
+    			add_location(div1, file$1, 37, 2, 1147);
     			attr_dev(div2, "class", "card mb-4 box-shadow");
     			set_style(div2, "cursor", "pointer");
-    			add_location(div2, file$1, 17, 0, 288);
+    			add_location(div2, file$1, 28, 0, 747);
     		},
     		m: function mount(target, anchor) {
     			insert_dev(target, div2, anchor);
@@ -5663,26 +5968,53 @@ var app = (function () {
     			append_dev(h6, t0);
     			append_dev(h6, t1);
     			append_dev(h6, t2);
-    			append_dev(div2, t3);
+    			append_dev(h6, t3);
+    			append_dev(h6, i_1);
+    			append_dev(div2, t4);
     			append_dev(div2, div1);
-    			append_dev(div1, t4);
-    			append_dev(div2, t5);
+    			append_dev(div1, t5);
+    			append_dev(div1, t6);
+    			if (if_block) if_block.m(div1, null);
+    			append_dev(div2, t7);
 
     			if (!mounted) {
-    				dispose = listen_dev(div2, "click", click_handler, false, false, false);
+    				dispose = [
+    					listen_dev(i_1, "click", stop_propagation(click_handler_1), false, false, true),
+    					listen_dev(div2, "click", click_handler, false, false, false)
+    				];
+
     				mounted = true;
     			}
     		},
     		p: function update(new_ctx, dirty) {
     			ctx = new_ctx;
-    			if (dirty & /*len*/ 2 && t0_value !== (t0_value = /*len*/ ctx[1] - /*i*/ ctx[7] + "")) set_data_dev(t0, t0_value);
-    			if (dirty & /*records*/ 1 && t2_value !== (t2_value = /*commit*/ ctx[4] + "")) set_data_dev(t2, t2_value);
-    			if (dirty & /*records*/ 1 && t4_value !== (t4_value = /*message*/ ctx[5] + "")) set_data_dev(t4, t4_value);
+    			if (dirty & /*records*/ 1 && t0_value !== (t0_value = /*number*/ ctx[8] + "")) set_data_dev(t0, t0_value);
+    			if (dirty & /*records*/ 1 && t2_value !== (t2_value = /*commit*/ ctx[9] + "")) set_data_dev(t2, t2_value);
+
+    			if (dirty & /*$baseline, records*/ 3) {
+    				set_style(i_1, "opacity", /*$baseline*/ ctx[1] === /*number*/ ctx[8] ? 1 : 0.3);
+    			}
+
+    			if (dirty & /*records*/ 1 && t5_value !== (t5_value = /*message*/ ctx[10] + "")) set_data_dev(t5, t5_value);
+
+    			if (/*id*/ ctx[11]) {
+    				if (if_block) {
+    					if_block.p(ctx, dirty);
+    				} else {
+    					if_block = create_if_block(ctx);
+    					if_block.c();
+    					if_block.m(div1, null);
+    				}
+    			} else if (if_block) {
+    				if_block.d(1);
+    				if_block = null;
+    			}
     		},
     		d: function destroy(detaching) {
     			if (detaching) detach_dev(div2);
+    			if (if_block) if_block.d();
     			mounted = false;
-    			dispose();
+    			run_all(dispose);
     		}
     	};
 
@@ -5690,7 +6022,7 @@ var app = (function () {
     		block,
     		id: create_each_block.name,
     		type: "each",
-    		source: "(17:0) {#each records as { commit, message }",
+    		source: "(28:0) {#each records as { number, commit, message, id, parts, received, complete }",
     		ctx
     	});
 
@@ -5698,7 +6030,16 @@ var app = (function () {
     }
 
     function create_fragment$1(ctx) {
+    	let div;
+    	let label;
+    	let input;
+    	let t0;
+    	let t1_value = (/*$baseline*/ ctx[1] ? `run ${/*$baseline*/ ctx[1]}` : "previous run") + "";
+    	let t1;
+    	let t2;
     	let each_1_anchor;
+    	let mounted;
+    	let dispose;
     	let each_value = /*records*/ ctx[0];
     	validate_each_argument(each_value);
     	let each_blocks = [];
@@ -5709,24 +6050,55 @@ var app = (function () {
 
     	const block = {
     		c: function create() {
+    			div = element("div");
+    			label = element("label");
+    			input = element("input");
+    			t0 = text("\n    compare with ");
+    			t1 = text(t1_value);
+    			t2 = space();
+
     			for (let i = 0; i < each_blocks.length; i += 1) {
     				each_blocks[i].c();
     			}
 
     			each_1_anchor = empty();
+    			attr_dev(input, "type", "checkbox");
+    			add_location(input, file$1, 22, 4, 532);
+    			add_location(label, file$1, 21, 2, 520);
+    			attr_dev(div, "class", "mb-4");
+    			add_location(div, file$1, 20, 0, 499);
     		},
     		l: function claim(nodes) {
     			throw new Error("options.hydrate only works if the component was compiled with the `hydratable: true` option");
     		},
     		m: function mount(target, anchor) {
+    			insert_dev(target, div, anchor);
+    			append_dev(div, label);
+    			append_dev(label, input);
+    			input.checked = /*$compare*/ ctx[2];
+    			append_dev(label, t0);
+    			append_dev(label, t1);
+    			insert_dev(target, t2, anchor);
+
     			for (let i = 0; i < each_blocks.length; i += 1) {
     				each_blocks[i].m(target, anchor);
     			}
 
     			insert_dev(target, each_1_anchor, anchor);
+
+    			if (!mounted) {
+    				dispose = listen_dev(input, "change", /*input_change_handler*/ ctx[5]);
+    				mounted = true;
+    			}
     		},
     		p: function update(ctx, [dirty]) {
-    			if (dirty & /*selectGraph, records, len*/ 7) {
+    			if (dirty & /*$compare*/ 4) {
+    				input.checked = /*$compare*/ ctx[2];
+    			}
+
+    			if (dirty & /*$baseline*/ 2 && t1_value !== (t1_value = (/*$baseline*/ ctx[1] ? `run ${/*$baseline*/ ctx[1]}` : "previous run") + "")) set_data_dev(t1, t1_value);
+
+    			if (dirty & /*selectGraph, records, toggleBaseline, $baseline*/ 27) {
     				each_value = /*records*/ ctx[0];
     				validate_each_argument(each_value);
     				let i;
@@ -5753,8 +6125,12 @@ var app = (function () {
     		i: noop,
     		o: noop,
     		d: function destroy(detaching) {
+    			if (detaching) detach_dev(div);
+    			if (detaching) detach_dev(t2);
     			destroy_each(each_blocks, detaching);
     			if (detaching) detach_dev(each_1_anchor);
+    			mounted = false;
+    			dispose();
     		}
     	};
 
@@ -5770,16 +6146,27 @@ var app = (function () {
     }
 
     function instance$1($$self, $$props, $$invalidate) {
+    	let $compare;
+    	let $baseline;
+    	validate_store(compare, "compare");
+    	component_subscribe($$self, compare, $$value => $$invalidate(2, $compare = $$value));
+    	validate_store(baseline, "baseline");
+    	component_subscribe($$self, baseline, $$value => $$invalidate(1, $baseline = $$value));
     	let records = [];
-    	let len = 0;
 
     	store.subscribe(value => {
     		$$invalidate(0, records = value);
-    		$$invalidate(1, len = records.length);
     	});
 
+    	// runs are selected by the number the server gave them, the panel only lists the latest ones
     	function selectGraph(index) {
     		current.update(() => records[index]);
+    		selected.set(records[index].number);
+    	}
+
+    	function toggleBaseline(index) {
+    		let number = records[index].number;
+    		baseline.update(n => n === number ? 0 : number);
     	}
 
     	const writable_props = [];
@@ -5790,26 +6177,46 @@ var app = (function () {
 
     	let { $$slots = {}, $$scope } = $$props;
     	validate_slots("Sidepanel", $$slots, []);
+
+    	function input_change_handler() {
+    		$compare = this.checked;
+    		compare.set($compare);
+    	}
+
     	const click_handler = i => selectGraph(i);
+    	const click_handler_1 = i => toggleBaseline(i);
 
     	$$self.$capture_state = () => ({
     		store,
     		current,
+    		selected,
+    		baseline,
+    		compare,
     		records,
-    		len,
-    		selectGraph
+    		selectGraph,
+    		toggleBaseline,
+    		$compare,
+    		$baseline
     	});
 
     	$$self.$inject_state = $$props => {
     		if ("records" in $$props) $$invalidate(0, records = $$props.records);
-    		if ("len" in $$props) $$invalidate(1, len = $$props.len);
     	};
 
     	if ($$props && "$$inject" in $$props) {
     		$$self.$inject_state($$props.$$inject);
     	}
 
-    	return [records, len, selectGraph, click_handler];
+    	return [
+    		records,
+    		$baseline,
+    		$compare,
+    		selectGraph,
+    		toggleBaseline,
+    		input_change_handler,
+    		click_handler,
+    		click_handler_1
+    	];
     }
 
     class Sidepanel extends SvelteComponentDev {
@@ -2,19 +2,21 @@ import { writable } from "svelte/store";
 
 let cats = [
   {
+    number: 2,
     commit: "df423i",
     message: "some new feature",
     nodes: [
-      { id: "group", group: 2, status: "pass" },
-      { id: "test", group: 2, status: "pass" },
+      { id: "group", label: "group", kind: "group", status: "pass" },
+      { id: "group/test", label: "test", kind: "test", group: "group", status: "pass" },
     ],
-    links: [{ source: "test", target: "group", value: 3 }],
+    links: [{ source: "group/test", target: "group", value: 1 }],
   },  {
+    number: 1,
     commit: "eabb33",
     message: "a fix for an old bug",
     nodes: [
-      { id: "second", group: 2, status: "pass" },
-      { id: "pair", group: 2, status: "pass" },
+      { id: "second", label: "second", kind: "group", status: "pass" },
+      { id: "pair", label: "pair", kind: "group", status: "pass" },
     ],
     links: [{ source: "second", target: "pair", value: 3 }],
   },
@@ -23,3 +25,11 @@ let cats = [
 export const store = writable(cats);
 
 export const current = writable({});
+
+// runs are numbered from 1 starting with the oldest, 0 selects the latest run
+export const selected = writable(0);
+
+// run the selected one is compared with, 0 selects the run preceding it
+export const baseline = writable(0);
+
+export const compare = writable(false);